@@ -10,7 +10,7 @@ import (
 )
 
 func usage() {
-	fmt.Fprintln(os.Stderr, "Usage: detect-latest-release [flags] {repo}\n\n  {repo} must be URL to GitHub repository or in 'owner/name' format.\n\nFlags:\n")
+	fmt.Fprint(os.Stderr, "Usage: detect-latest-release [flags] {repo}\n\n  {repo} must be URL to GitHub repository or in 'owner/name' format.\n\nFlags:\n\n")
 	flag.PrintDefaults()
 }
 
@@ -59,7 +59,8 @@ func main() {
 		} else {
 			fmt.Println(latest.Version)
 			if *notes {
-				fmt.Printf("\nRelease Notes:\n%s\n", latest.ReleaseNotes)
+				fmt.Print("\nRelease Notes:\n")
+				selfupdate.WriteReleaseNotes(os.Stdout, latest.ReleaseNotes)
 			}
 		}
 	}
@@ -127,6 +127,6 @@ func main() {
 	fmt.Printf(`Command was updated to the latest version %s: %s
 
 Release Notes:
-%s
-`, latest.Version, cmdPath, latest.ReleaseNotes)
+`, latest.Version, cmdPath)
+	selfupdate.WriteReleaseNotes(os.Stdout, latest.ReleaseNotes)
 }
@@ -19,17 +19,18 @@ func selfUpdate(slug string) error {
 		return err
 	}
 
-	if previous.Equals(latest.Version) {
-		fmt.Println("Current binary is the latest version", version)
+	if latest.Status != selfupdate.Updated {
+		fmt.Println("Current binary is not updated:", latest.Status)
 	} else {
 		fmt.Println("Update successfully done to version", latest.Version)
-		fmt.Println("Release note:\n", latest.ReleaseNotes)
+		fmt.Println("Release note:")
+		selfupdate.WriteReleaseNotes(os.Stdout, latest.ReleaseNotes)
 	}
 	return nil
 }
 
 func usage() {
-	fmt.Fprintln(os.Stderr, "Usage: selfupdate-example [flags]\n")
+	fmt.Fprint(os.Stderr, "Usage: selfupdate-example [flags]\n\n")
 	flag.PrintDefaults()
 }
 
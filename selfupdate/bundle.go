@@ -0,0 +1,248 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+const (
+	// bundleMetadataName is the name of the entry of release metadata in a bundle.
+	bundleMetadataName = "release.json"
+	// bundleAssetDir is the directory of the asset entry in a bundle.
+	bundleAssetDir = "asset/"
+	// bundleValidationDir is the directory of the validation file entry in a bundle.
+	bundleValidationDir = "validation/"
+)
+
+// bundleMetadata is the metadata of the release stored in a bundle.
+type bundleMetadata struct {
+	Version      string     `json:"version"`
+	TagName      string     `json:"tag_name"`
+	Name         string     `json:"name"`
+	URL          string     `json:"url"`
+	ReleaseNotes string     `json:"release_notes"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	RepoOwner    string     `json:"repo_owner"`
+	RepoName     string     `json:"repo_name"`
+	AssetName    string     `json:"asset_name"`
+}
+
+func writeBundleEntry(w *tar.Writer, name string, size int64, src io.Reader) error {
+	if err := w.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("Failed to write %s to bundle: %s", name, err)
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("Failed to write %s to bundle: %s", name, err)
+	}
+	return nil
+}
+
+// ExportBundle downloads the asset and the validation file of the release and packages them with the metadata
+// of the release into a single file at 'path'. The asset is validated with the validator before exporting.
+// The bundle can be carried to offline machines and applied with UpdateFromBundle. The bundle is a tar
+// archive which contains 'release.json', 'asset/{asset name}' and 'validation/{validation file name}'.
+func (up *Updater) ExportBundle(rel *Release, path string) error {
+	f, validationData, cleanup, err := up.downloadVerifiedAsset(rel)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("Failed to stat downloaded asset file %s: %s", f.Name(), err)
+	}
+
+	meta, err := json.MarshalIndent(&bundleMetadata{
+		Version:      rel.Version.String(),
+		TagName:      rel.TagName,
+		Name:         rel.Name,
+		URL:          rel.URL,
+		ReleaseNotes: rel.ReleaseNotes,
+		PublishedAt:  rel.PublishedAt,
+		RepoOwner:    rel.RepoOwner,
+		RepoName:     rel.RepoName,
+		AssetName:    rel.AssetName,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("Failed to create bundle file: %s", err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	w := tar.NewWriter(out)
+	if err := writeBundleEntry(w, bundleMetadataName, int64(len(meta)), bytes.NewReader(meta)); err != nil {
+		return err
+	}
+	if err := writeBundleEntry(w, bundleAssetDir+rel.AssetName, stat.Size(), f); err != nil {
+		return err
+	}
+	if up.validator != nil {
+		name := bundleValidationDir + rel.AssetName + up.validator.Suffix()
+		if err := writeBundleEntry(w, name, int64(len(validationData)), bytes.NewReader(validationData)); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Failed to write bundle: %s", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("Failed to write bundle: %s", err)
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return fmt.Errorf("Failed to create bundle %s: %s", path, err)
+	}
+
+	log.Println("Exported version", rel.Version, "to bundle", path)
+	return nil
+}
+
+// UpdateFromBundle updates the command with the bundle exported by ExportBundle without accessing network.
+// The asset in the bundle is validated with the configured validator and the executable is extracted from it in
+// the same way as UpdateTo, so an offline update gets the same checks as an online one. When a validator is
+// configured, the bundle must contain the validation file for it. It returns the release in the bundle.
+func (up *Updater) UpdateFromBundle(path, cmdPath string) (*Release, error) {
+	cmdPath, err := resolveCommandPath(cmdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open bundle: %s", err)
+	}
+	defer b.Close()
+
+	var meta *bundleMetadata
+	var asset string
+	var f *os.File
+	var h hash.Hash
+	cleanup := func() {}
+	validations := map[string][]byte{}
+	defer func() { cleanup() }()
+
+	r := tar.NewReader(b)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read bundle %s: %s", path, err)
+		}
+
+		switch {
+		case hdr.Name == bundleMetadataName:
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read %s in bundle: %s", hdr.Name, err)
+			}
+			meta = &bundleMetadata{}
+			if err := json.Unmarshal(data, meta); err != nil {
+				return nil, fmt.Errorf("Broken %s in bundle %s: %s", hdr.Name, path, err)
+			}
+		case strings.HasPrefix(hdr.Name, bundleAssetDir):
+			if f != nil {
+				return nil, fmt.Errorf("Bundle %s contains multiple assets", path)
+			}
+			asset = strings.TrimPrefix(hdr.Name, bundleAssetDir)
+			h = up.newHash()
+			tmp, c, err := up.downloadToFile(r, hdr.Size, h)
+			if err != nil {
+				return nil, err
+			}
+			f, cleanup = tmp, c
+		case strings.HasPrefix(hdr.Name, bundleValidationDir):
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read %s in bundle: %s", hdr.Name, err)
+			}
+			validations[strings.TrimPrefix(hdr.Name, bundleValidationDir)] = data
+		}
+	}
+
+	if meta == nil {
+		return nil, fmt.Errorf("Bundle %s does not contain %s", path, bundleMetadataName)
+	}
+	if f == nil || asset != meta.AssetName {
+		return nil, fmt.Errorf("Bundle %s does not contain asset %q", path, meta.AssetName)
+	}
+	v, err := semver.Parse(meta.Version)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid version in bundle %s: %s", path, err)
+	}
+
+	if up.validator != nil {
+		name := asset + up.validator.Suffix()
+		data, ok := validations[name]
+		if !ok {
+			return nil, fmt.Errorf("Failed finding validation file %q in bundle %s", name, path)
+		}
+		data, err := up.selectValidationData(asset, data)
+		if err != nil {
+			return nil, err
+		}
+		if err := up.validateAsset(f, h, data); err != nil {
+			return nil, err
+		}
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to stat asset file %s: %s", f.Name(), err)
+	}
+	rel := &Release{
+		Version:           v,
+		AssetURL:          asset,
+		AssetName:         asset,
+		AssetByteSize:     int(stat.Size()),
+		AssetID:           -1,
+		ValidationAssetID: -1,
+		TagName:           meta.TagName,
+		URL:               meta.URL,
+		ReleaseNotes:      meta.ReleaseNotes,
+		Name:              meta.Name,
+		PublishedAt:       meta.PublishedAt,
+		RepoOwner:         meta.RepoOwner,
+		RepoName:          meta.RepoName,
+	}
+
+	log.Println("Will update", cmdPath, "to version", v, "from bundle", path)
+	if err := up.uncompressAndUpdate(f, archiveFormat(asset), asset, cmdPath); err != nil {
+		return nil, err
+	}
+	return rel, nil
+}
+
+// ExportBundle downloads the release and packages it into a single file at 'path'.
+// This function is a shortcut version of updater.ExportBundle.
+func ExportBundle(rel *Release, path string) error {
+	return DefaultUpdater().ExportBundle(rel, path)
+}
+
+// UpdateFromBundle updates the command with the bundle exported by ExportBundle.
+// This function is a shortcut version of updater.UpdateFromBundle. Since no validator is configured for
+// the default updater, please use the method to validate the asset.
+func UpdateFromBundle(path, cmdPath string) (*Release, error) {
+	return DefaultUpdater().UpdateFromBundle(path, cmdPath)
+}
@@ -0,0 +1,206 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func exportTestBundle(t *testing.T, dir string) string {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+	latest, found, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("Release was not found")
+	}
+	path := filepath.Join(dir, "update.bundle")
+	if err := up.ExportBundle(latest, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rewriteBundle rewrites the entries of the bundle with the function. When the function returns nil, the entry
+// is removed from the bundle.
+func rewriteBundle(t *testing.T, path string, f func(name string, data []byte) []byte) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	r := tar.NewReader(bytes.NewReader(b))
+	w := tar.NewWriter(&out)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = f(hdr.Name, data)
+		if data == nil {
+			continue
+		}
+		hdr.Size = int64(len(data))
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportAndUpdateFromBundle(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	path := exportTestBundle(t, dir)
+
+	entries := []string{}
+	rewriteBundle(t, path, func(name string, data []byte) []byte {
+		entries = append(entries, name)
+		return data
+	})
+	asset := platformAsset("foo")
+	want := []string{"release.json", "asset/" + asset, "validation/" + asset + ".sha256"}
+	if strings.Join(entries, ",") != strings.Join(want, ",") {
+		t.Errorf("Wanted entries %q but got %q", want, entries)
+	}
+
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+
+	// No network access is needed to update from the bundle
+	up, err := NewUpdater(Config{Validator: &SHA2Validator{}, EnterpriseBaseURL: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := up.UpdateFromBundle(path, exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rel.Version.Equals(semver.MustParse("1.1.0")) || rel.AssetName != asset || rel.RepoOwner != "owner" || rel.RepoName != "repo" {
+		t.Errorf("Unexpected release in bundle: %+v", rel)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated from bundle: %q", got)
+	}
+}
+
+func TestUpdateFromBundleValidation(t *testing.T) {
+	asset := platformAsset("foo")
+	for _, tc := range []struct {
+		what    string
+		rewrite func(name string, data []byte) []byte
+		want    string
+	}{
+		{
+			what: "tampered asset",
+			rewrite: func(name string, data []byte) []byte {
+				if name == "asset/"+asset {
+					return zipRelease(t, "v6.6.6", "").files[asset]
+				}
+				return data
+			},
+			want: "Failed validating asset content",
+		},
+		{
+			what: "missing validation file",
+			rewrite: func(name string, data []byte) []byte {
+				if strings.HasPrefix(name, "validation/") {
+					return nil
+				}
+				return data
+			},
+			want: "Failed finding validation file",
+		},
+		{
+			what: "missing metadata",
+			rewrite: func(name string, data []byte) []byte {
+				if name == "release.json" {
+					return nil
+				}
+				return data
+			},
+			want: "does not contain release.json",
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			dir, cleanup := newTestCacheDir(t)
+			defer cleanup()
+			path := exportTestBundle(t, dir)
+			rewriteBundle(t, path, tc.rewrite)
+
+			exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+			defer cleanupExe()
+
+			up, err := NewUpdater(Config{Validator: &SHA2Validator{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = up.UpdateFromBundle(path, exe)
+			if err == nil {
+				t.Fatal("Error should occur")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Error should contain %q but got %q", tc.want, err.Error())
+			}
+			if got := readTestExecutable(t, exe); got != "v1.0.0" {
+				t.Errorf("Executable should not be updated: %q", got)
+			}
+		})
+	}
+}
+
+func TestExportBundleKeepsNoPartialFile(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	rel := zipRelease(t, "v1.1.0", "0000000000000000000000000000000000000000000000000000000000000000")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "update.bundle")
+	if err := up.ExportBundle(latest, path); err == nil {
+		t.Fatal("Invalid asset should not be exported")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Bundle should not be created:", err)
+	}
+	fs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 0 {
+		t.Error("No file should remain:", fs[0].Name())
+	}
+}
@@ -0,0 +1,244 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCacheMaxBytes is the maximum total size of cached assets used when Config.SharedCacheMaxBytes is zero.
+	defaultCacheMaxBytes = 1024 * 1024 * 1024
+	// cacheLockTimeout is how long to wait for the lock of the cache directory held by other processes.
+	cacheLockTimeout = 30 * time.Second
+)
+
+// assetCache is an on-disk cache of downloaded assets shared by tools and processes. Assets are stored by
+// their SHA-256 digests in 'objects' directory so that identical assets are stored only once. Each asset is
+// identified by a key which includes the API host, the repository and the asset ID since asset IDs are not unique
+// across GitHub hosts, and the key is mapped to the digest by a file in 'index' directory. All operations are serialized among processes by
+// an advisory lock of a lock file.
+type assetCache struct {
+	dir      string
+	maxBytes int64
+}
+
+func newAssetCache(dir string, maxBytes int64) *assetCache {
+	if maxBytes == 0 {
+		maxBytes = defaultCacheMaxBytes
+	}
+	return &assetCache{dir: dir, maxBytes: maxBytes}
+}
+
+func (c *assetCache) indexPath(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, "index", hex.EncodeToString(h[:]))
+}
+
+func (c *assetCache) objectPath(digest string) string {
+	return filepath.Join(c.dir, "objects", digest)
+}
+
+// lock acquires the lock of the cache directory with OS advisory locking (flock on Unix and LockFileEx on
+// Windows) of a lock file. Since the OS releases the lock when its holder exits, a lock is never left by a
+// crashed process and the lock file is never removed. The returned function releases the lock.
+func (c *assetCache) lock() (func(), error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create cache directory %s: %s", c.dir, err)
+	}
+	path := filepath.Join(c.dir, "lock")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open lock file %s: %s", path, err)
+	}
+	deadline := time.Now().Add(cacheLockTimeout)
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("Failed to lock %s: %s", path, err)
+		}
+		if ok {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("Timed out waiting for lock file %s of cache", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// readIndex returns the digest and the size of the asset recorded in the index.
+func (c *assetCache) readIndex(key string) (string, int64, bool) {
+	b, err := ioutil.ReadFile(c.indexPath(key))
+	if err != nil {
+		return "", 0, false
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 {
+		return "", 0, false
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[0], size, true
+}
+
+// get returns the cached file of the asset. The content is verified against the digest and the size recorded
+// when it was stored. When 'size' is positive, the size of the cached asset must also be equal to it. A broken
+// entry is removed from the cache. The caller must close the returned file.
+func (c *assetCache) get(key string, size int64) (*os.File, bool) {
+	unlock, err := c.lock()
+	if err != nil {
+		log.Println("Could not look up cache:", err)
+		return nil, false
+	}
+	defer unlock()
+
+	digest, recorded, ok := c.readIndex(key)
+	if !ok {
+		return nil, false
+	}
+	if size > 0 && recorded != size {
+		log.Println("Size of cached asset", key, "does not match. Ignoring the cache entry")
+		os.Remove(c.indexPath(key))
+		return nil, false
+	}
+
+	path := c.objectPath(digest)
+	f, err := os.Open(path)
+	if err != nil {
+		os.Remove(c.indexPath(key))
+		return nil, false
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil || n != recorded || hex.EncodeToString(h.Sum(nil)) != digest {
+		log.Println("Cached asset", path, "is broken. Removing it from cache")
+		f.Close()
+		os.Remove(path)
+		os.Remove(c.indexPath(key))
+		return nil, false
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, false
+	}
+
+	// Update modification time for evicting least recently used assets
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	log.Println("Found asset", key, "in cache", path)
+	return f, true
+}
+
+// put stores the content read from the source as the asset in the cache and evicts least recently used assets
+// when the total size of cached assets exceeds the limit.
+func (c *assetCache) put(key string, src io.Reader) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, d := range []string{"objects", "index"} {
+		if err := os.MkdirAll(filepath.Join(c.dir, d), 0755); err != nil {
+			return fmt.Errorf("Failed to create cache directory: %s", err)
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Join(c.dir, "objects"), "tmp-")
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file in cache: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), src)
+	tmp.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to write asset %s to cache: %s", key, err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	if err := os.Rename(tmp.Name(), c.objectPath(digest)); err != nil {
+		return fmt.Errorf("Failed to store asset %s in cache: %s", key, err)
+	}
+
+	index := c.indexPath(key)
+	if err := ioutil.WriteFile(index+".tmp", []byte(fmt.Sprintf("%s %d\n", digest, size)), 0644); err != nil {
+		return fmt.Errorf("Failed to write index of asset %s in cache: %s", key, err)
+	}
+	if err := os.Rename(index+".tmp", index); err != nil {
+		return fmt.Errorf("Failed to write index of asset %s in cache: %s", key, err)
+	}
+	log.Println("Stored asset", key, "in cache", c.objectPath(digest))
+
+	c.evict()
+	return nil
+}
+
+// evict removes least recently used assets until the total size of cached assets is within the limit.
+// Index entries of removed assets are removed lazily when they are looked up. It must be called while
+// the lock is held.
+func (c *assetCache) evict() {
+	if c.maxBytes < 0 {
+		return
+	}
+	fs, err := ioutil.ReadDir(filepath.Join(c.dir, "objects"))
+	if err != nil {
+		return
+	}
+	objs := make([]os.FileInfo, 0, len(fs))
+	total := int64(0)
+	for _, f := range fs {
+		if f.IsDir() || strings.HasPrefix(f.Name(), "tmp-") {
+			continue
+		}
+		objs = append(objs, f)
+		total += f.Size()
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].ModTime().Before(objs[j].ModTime())
+	})
+	for _, f := range objs {
+		if total <= c.maxBytes {
+			break
+		}
+		log.Println("Evicting", f.Name(), "from cache to keep its size within", c.maxBytes, "bytes")
+		if err := os.Remove(c.objectPath(f.Name())); err != nil {
+			log.Println("Could not evict cached asset:", err)
+			continue
+		}
+		total -= f.Size()
+	}
+}
+
+// readAll returns the content of the cached asset.
+func (c *assetCache) readAll(key string) ([]byte, bool) {
+	f, ok := c.get(key, -1)
+	if !ok {
+		return nil, false
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
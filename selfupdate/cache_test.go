@@ -0,0 +1,232 @@
+package selfupdate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func TestAssetCachePutGet(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	c := newAssetCache(dir, 0)
+	if _, ok := c.get("asset-1", -1); ok {
+		t.Fatal("Empty cache should not have any asset")
+	}
+	if err := c.put("asset-1", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	// The same content is shared by different assets
+	if err := c.put("asset-2", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	objs, err := ioutil.ReadDir(filepath.Join(dir, "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 {
+		t.Errorf("Identical assets should be stored once but %d objects exist", len(objs))
+	}
+
+	for _, id := range []string{"asset-1", "asset-2"} {
+		b, ok := c.readAll(id)
+		if !ok {
+			t.Fatal("Asset was not found in cache:", id)
+		}
+		if string(b) != "hello" {
+			t.Errorf("Unexpected cached content of asset %s: %q", id, b)
+		}
+	}
+
+	if _, ok := c.get("asset-1", 3); ok {
+		t.Error("Cached asset whose size does not match should be ignored")
+	}
+}
+
+func TestAssetCacheBrokenObject(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	c := newAssetCache(dir, 0)
+	if err := c.put("asset-1", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	digest, _, ok := c.readIndex("asset-1")
+	if !ok {
+		t.Fatal("Index was not written")
+	}
+	if err := ioutil.WriteFile(c.objectPath(digest), []byte("hallo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.get("asset-1", -1); ok {
+		t.Fatal("Broken asset should not be returned from cache")
+	}
+	if _, err := os.Stat(c.objectPath(digest)); !os.IsNotExist(err) {
+		t.Error("Broken asset should be removed from cache:", err)
+	}
+	if _, err := os.Stat(c.indexPath("asset-1")); !os.IsNotExist(err) {
+		t.Error("Index of broken asset should be removed from cache:", err)
+	}
+}
+
+func TestAssetCacheEviction(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	c := newAssetCache(dir, 10)
+	if err := c.put("asset-1", strings.NewReader("aaaaaa")); err != nil {
+		t.Fatal(err)
+	}
+	digest1, _, _ := c.readIndex("asset-1")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(c.objectPath(digest1), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.put("asset-2", strings.NewReader("bbbbbb")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.get("asset-1", -1); ok {
+		t.Error("Least recently used asset should be evicted")
+	}
+	if _, ok := c.readAll("asset-2"); !ok {
+		t.Error("Recently stored asset should remain")
+	}
+
+	unlimited := newAssetCache(dir, -1)
+	if err := unlimited.put("asset-3", strings.NewReader("cccccc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := unlimited.readAll("asset-2"); !ok {
+		t.Error("Asset should not be evicted when size of cache is not limited")
+	}
+}
+
+func TestAssetCacheLock(t *testing.T) {
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	c := newAssetCache(dir, 0)
+	unlock, err := c.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		u, err := c.lock()
+		if err != nil {
+			t.Error(err)
+			close(acquired)
+			return
+		}
+		close(acquired)
+		u()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Lock should not be acquired while other holder has it")
+	case <-time.After(200 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lock should be acquired after it was released")
+	}
+
+	// Lock file left by a crashed process does not block others since its lock was released by the OS
+	path := filepath.Join(dir, "lock")
+	if err := ioutil.WriteFile(path, []byte("12345\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = c.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestUpdateCommandFromSharedCache(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	asset := platformAsset("foo")
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	downloads := 0
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write(rel.files[asset])
+	})
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/201", func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write(rel.files[asset+".sha256"])
+	})
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+		defer cleanupExe()
+		up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}, SharedCacheDir: dir})
+		if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+			t.Fatal(err)
+		}
+		if got := readTestExecutable(t, exe); got != "v1.1.0" {
+			t.Errorf("Executable was not updated: %q", got)
+		}
+	}
+	if downloads != 2 {
+		t.Errorf("Asset and validation file should be downloaded only once but downloaded %d times", downloads)
+	}
+
+	// Cached asset which does not pass the validation is not used
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}, SharedCacheDir: dir})
+	c := newAssetCache(dir, 0)
+	tampered := zipRelease(t, "v6.6.6", "").files[asset]
+	if err := c.put(up.cacheKey(&Release{RepoOwner: "owner", RepoName: "repo"}, 200), bytes.NewReader(tampered)); err != nil {
+		t.Fatal(err)
+	}
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+	if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable should be updated with downloaded asset: %q", got)
+	}
+	if downloads != 3 {
+		t.Errorf("Asset should be downloaded again when cached asset is invalid: %d", downloads)
+	}
+}
+
+func TestSharedCacheKey(t *testing.T) {
+	srv, _ := newTestAPIServer(t, nil)
+	defer srv.Close()
+
+	enterprise := newTestUpdater(t, srv, Config{})
+	public := DefaultUpdater()
+	rel := &Release{RepoOwner: "owner", RepoName: "repo"}
+	other := &Release{RepoOwner: "owner", RepoName: "other"}
+
+	if enterprise.cacheKey(rel, 200) == public.cacheKey(rel, 200) {
+		t.Error("Assets on different GitHub hosts should not share cache entry:", public.cacheKey(rel, 200))
+	}
+	if public.cacheKey(rel, 200) == public.cacheKey(other, 200) {
+		t.Error("Assets of different repositories should not share cache entry:", public.cacheKey(rel, 200))
+	}
+	if public.cacheKey(rel, 200) != DefaultUpdater().cacheKey(rel, 200) {
+		t.Error("Cache key of the same asset should be stable")
+	}
+}
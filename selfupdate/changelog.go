@@ -0,0 +1,84 @@
+package selfupdate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+// ReleasesBetween returns releases whose versions are in the range (current, target] from the given releases.
+// 'rels' is typically a list returned from ListReleases. Returned releases are sorted from the oldest version
+// to the newest one so that their release notes can be read in the order of changes.
+func ReleasesBetween(rels []*Release, current, target semver.Version) []*Release {
+	between := make([]*Release, 0, len(rels))
+	for _, r := range rels {
+		if r.Version.GT(current) && r.Version.LTE(target) {
+			between = append(between, r)
+		}
+	}
+	sort.SliceStable(between, func(i, j int) bool {
+		return between[i].Version.LT(between[j].Version)
+	})
+	return between
+}
+
+// changes collects releases whose versions are in the range (current, target] from the releases fetched from
+// GitHub API. Unlike the releases detected for update, releases which are yanked, not rolled out yet, too new,
+// or without an asset for the platform are also collected since their release notes may still describe
+// changes to the target. Only drafts (unless Config.IncludeDrafts is set) and pre-releases are skipped.
+func (up *Updater) changes(owner, repo string, rels []*github.RepositoryRelease, current, target semver.Version) []*Release {
+	cands := make([]*Release, 0, len(rels))
+	for _, rel := range rels {
+		if (rel.GetDraft() && !up.drafts) || rel.GetPrerelease() {
+			continue
+		}
+		v, ok := parseTagVersion(rel.GetTagName())
+		if !ok {
+			continue
+		}
+		publishedAt := rel.GetPublishedAt().Time
+		cands = append(cands, &Release{
+			Version:           v,
+			AssetID:           -1,
+			ValidationAssetID: -1,
+			TagName:           rel.GetTagName(),
+			URL:               rel.GetHTMLURL(),
+			ReleaseNotes:      rel.GetBody(),
+			Name:              rel.GetName(),
+			PublishedAt:       &publishedAt,
+			RepoOwner:         owner,
+			RepoName:          repo,
+			Draft:             rel.GetDraft(),
+			Critical:          parseReleaseMetadata(rel.GetBody()).bool("critical"),
+		})
+	}
+	return ReleasesBetween(cands, current, target)
+}
+
+// Changelog renders release notes of the given releases as one changelog in markdown. Release notes of each
+// release are put under a heading of its version in the same order as the given releases.
+// Combined with ReleasesBetween or Changes field of the result of Check and UpdateCommand, it shows all changes
+// between the current version and the target version.
+func Changelog(rels []*Release) string {
+	var b strings.Builder
+	for i, r := range rels {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## v")
+		b.WriteString(r.Version.String())
+		if r.Name != "" && strings.TrimPrefix(r.Name, "v") != r.Version.String() {
+			b.WriteString(" - ")
+			b.WriteString(r.Name)
+		}
+		b.WriteString("\n")
+		if notes := strings.TrimSpace(r.ReleaseNotes); notes != "" {
+			b.WriteString("\n")
+			b.WriteString(notes)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package selfupdate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func testReleasesForChangelog() []*Release {
+	rels := []*Release{}
+	for _, v := range []string{"1.6.0", "1.5.0", "1.4.1", "1.3.0", "1.2.0", "1.1.0"} {
+		rels = append(rels, &Release{
+			Version:      semver.MustParse(v),
+			Name:         "v" + v,
+			ReleaseNotes: "Notes for " + v + "\n",
+		})
+	}
+	return rels
+}
+
+func TestReleasesBetween(t *testing.T) {
+	rels := testReleasesForChangelog()
+
+	for _, tc := range []struct {
+		current string
+		target  string
+		want    []string
+	}{
+		{"1.2.0", "1.6.0", []string{"1.3.0", "1.4.1", "1.5.0", "1.6.0"}},
+		{"1.2.0", "1.4.0", []string{"1.3.0"}},
+		{"1.3.5", "1.5.0", []string{"1.4.1", "1.5.0"}},
+		{"1.6.0", "1.6.0", []string{}},
+		{"1.6.0", "1.2.0", []string{}},
+		{"0.1.0", "1.1.0", []string{"1.1.0"}},
+	} {
+		got := ReleasesBetween(rels, semver.MustParse(tc.current), semver.MustParse(tc.target))
+		if len(got) != len(tc.want) {
+			t.Errorf("Wanted %v for (%s, %s] but got %d releases", tc.want, tc.current, tc.target, len(got))
+			continue
+		}
+		for i, r := range got {
+			if r.Version.String() != tc.want[i] {
+				t.Errorf("Wanted %s at %d for (%s, %s] but got %s", tc.want[i], i, tc.current, tc.target, r.Version)
+			}
+		}
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	rels := ReleasesBetween(testReleasesForChangelog(), semver.MustParse("1.3.0"), semver.MustParse("1.5.0"))
+	rels[1].Name = "Big release"
+	rels[0].ReleaseNotes = ""
+
+	want := `## v1.4.1
+
+## v1.5.0 - Big release
+
+Notes for 1.5.0
+`
+	if got := Changelog(rels); got != want {
+		t.Errorf("Wanted %q but got %q", want, got)
+	}
+
+	if got := Changelog(nil); got != "" {
+		t.Errorf("Changelog of no release should be empty but got %q", got)
+	}
+}
+
+func TestUpdateCommandReturnsChanges(t *testing.T) {
+	yanked := binaryRelease("v1.3.0")
+	yanked.body = "<!-- selfupdate\nyanked: true\n-->\nBreaking change in 1.3.0"
+	noAsset := testRelease{tag: "v1.4.0", body: "Breaking change in 1.4.0"}
+	prerelease := binaryRelease("v1.5.0-beta")
+	prerelease.prerelease = true
+	draft := binaryRelease("v1.7.0")
+	draft.draft = true
+	srv, _ := newTestAPIServer(t, []testRelease{
+		binaryRelease("v1.2.0"),
+		yanked,
+		noAsset,
+		prerelease,
+		binaryRelease("v1.5.0"),
+		binaryRelease("v1.6.0"),
+		draft,
+	})
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.2.0")
+	defer cleanup()
+
+	res, err := newTestUpdater(t, srv, Config{}).UpdateCommand(exe, semver.MustParse("1.2.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Updated || res.Version.String() != "1.6.0" {
+		t.Fatalf("Command should be updated to 1.6.0: %+v", res)
+	}
+	want := []string{"1.3.0", "1.4.0", "1.5.0", "1.6.0"}
+	if len(res.Changes) != len(want) {
+		t.Fatalf("Wanted changes %v but got %d releases", want, len(res.Changes))
+	}
+	for i, r := range res.Changes {
+		if r.Version.String() != want[i] {
+			t.Errorf("Wanted %s at %d but got %s", want[i], i, r.Version)
+		}
+		if r.RepoOwner != "owner" || r.RepoName != "repo" {
+			t.Errorf("Repository of release %s is not set: %+v", r.Version, r)
+		}
+	}
+	if log := Changelog(res.Changes); !strings.Contains(log, "Breaking change in 1.3.0") || !strings.Contains(log, "Breaking change in 1.4.0") {
+		t.Errorf("Changelog should contain notes of all releases: %q", log)
+	}
+
+	res, err = newTestUpdater(t, srv, Config{}).UpdateCommand(exe, semver.MustParse("1.6.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changes != nil {
+		t.Error("No change should be returned when update was skipped:", res.Changes)
+	}
+}
@@ -0,0 +1,229 @@
+package selfupdate
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+// UpdateStatus represents the status of the current version compared with releases.
+type UpdateStatus int
+
+const (
+	// UpToDate means that the current version is the latest release which can be updated to.
+	UpToDate UpdateStatus = iota
+	// UpdateAvailable means that a release which the current version can be updated to exists.
+	UpdateAvailable
+	// Ahead means that the current version is newer than the latest release. This happens when the
+	// current version is a development build.
+	Ahead
+	// NoRelease means that no release for current OS and arch was found.
+	NoRelease
+	// Blocked means that newer releases exist but the update policy rejected all of them.
+	Blocked
+	// Updated means that the command was updated. This status is only set by UpdateCommand and UpdateSelf.
+	Updated
+	// Disabled means that self-update was disabled by the user via $<TOOL>_SELFUPDATE or the control file.
+	Disabled
+	// Pinned means that the current version is pinned by the user via $<TOOL>_VERSION_PIN or the control file.
+	Pinned
+)
+
+func (s UpdateStatus) String() string {
+	switch s {
+	case UpToDate:
+		return "up-to-date"
+	case UpdateAvailable:
+		return "update available"
+	case Ahead:
+		return "ahead"
+	case NoRelease:
+		return "no release"
+	case Blocked:
+		return "blocked"
+	case Updated:
+		return "updated"
+	case Disabled:
+		return "disabled"
+	case Pinned:
+		return "pinned"
+	default:
+		return fmt.Sprintf("UpdateStatus(%d)", int(s))
+	}
+}
+
+// CheckResult represents the result of checking an update with Check.
+type CheckResult struct {
+	// Status is the status of the current version.
+	Status UpdateStatus
+	// Current is the current version.
+	Current semver.Version
+	// Release is the candidate release. When the status is UpdateAvailable, it is the release which the
+	// current version would be updated to. When the status is UpToDate, it is the release of the current
+	// version. When the status is Ahead or Blocked, it is the latest release. When the status is NoRelease,
+	// it is nil.
+	Release *Release
+	// Decision is the decision of the update policy configured in Config.Policy.
+	Decision PolicyDecision
+	// Pending is the release which would be the latest but was ignored because it was published more recently
+	// than Config.MinReleaseAge. It is nil when no release is pending.
+	Pending *Release
+	// Changes are releases between the current version and the candidate release including the candidate,
+	// sorted from the oldest to the newest. They are set when the status is UpdateAvailable. Releases which are
+	// not candidates of update such as yanked ones are also included so that all release notes up to the
+	// candidate can be shown with Changelog.
+	Changes []*Release
+	// Reason explains why the status was decided.
+	Reason string
+}
+
+// checkPinned checks the current version against the version pinned by the user. Update policy, minimum
+// release age and so on are not applied to the pinned version since the user chose it explicitly.
+func (up *Updater) checkPinned(owner, repo string, rels []*github.RepositoryRelease, current semver.Version, c *updateControls) (*CheckResult, error) {
+	pin := *c.pin
+	result := &CheckResult{Current: current, Decision: PolicyDecision{Allowed: true}}
+	if current.Equals(pin) {
+		result.Status = Pinned
+		result.Reason = fmt.Sprintf("version is pinned to %s by %s", pin, c.pinnedBy)
+		return result, nil
+	}
+
+	cands, _ := up.findCandidates(rels, pin.String(), false)
+	cand := latestCandidate(cands)
+	if cand == nil {
+		result.Status = NoRelease
+		result.Reason = fmt.Sprintf("release of version %s pinned by %s was not found for %s/%s", pin, c.pinnedBy, runtime.GOOS, runtime.GOARCH)
+		return result, nil
+	}
+
+	rel, err := up.newRelease(owner, repo, rels, cand.release, cand.asset, cand.version)
+	if err != nil {
+		return nil, err
+	}
+	rel.Patch = findPatch(rel.Patches, current)
+	result.Status = UpdateAvailable
+	result.Release = rel
+	result.Changes = up.changes(owner, repo, rels, current, rel.Version)
+	result.Reason = fmt.Sprintf("version is pinned to %s by %s", pin, c.pinnedBy)
+	return result, nil
+}
+
+// fetchAndCheck checks the current version against releases of the repository. The controls of self-update
+// are evaluated before fetching releases so that the GitHub API is not accessed at all when self-update is
+// disabled. It also returns the fetched releases, which are nil when self-update is disabled.
+func (up *Updater) fetchAndCheck(owner, repo string, current semver.Version) (*CheckResult, []*github.RepositoryRelease, error) {
+	tool := up.toolName
+	if tool == "" {
+		tool = repo
+	}
+	ctl, err := up.loadControls(tool)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ctl.disabled {
+		reason := fmt.Sprintf("self-update is disabled by %s", ctl.disabledBy)
+		log.Println("Skip checking update because", reason)
+		return &CheckResult{Status: Disabled, Current: current, Decision: PolicyDecision{Allowed: true}, Reason: reason}, nil, nil
+	}
+
+	rels, err := up.fetchReleases(owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := up.check(owner, repo, rels, current, ctl)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, rels, nil
+}
+
+func (up *Updater) check(owner, repo string, rels []*github.RepositoryRelease, current semver.Version, ctl *updateControls) (*CheckResult, error) {
+	if ctl.pin != nil {
+		return up.checkPinned(owner, repo, rels, current, ctl)
+	}
+
+	cands, soaking := up.findCandidates(rels, "", false)
+
+	var pending *Release
+	if p := up.pendingCandidate(soaking, latestCandidate(cands)); p != nil {
+		r, err := up.newRelease(owner, repo, rels, p.release, p.asset, p.version)
+		if err != nil {
+			log.Println("Could not create pending release", p.version, ":", err)
+		}
+		pending = r
+	}
+
+	if len(cands) == 0 {
+		reason := fmt.Sprintf("no release was found for %s/%s", runtime.GOOS, runtime.GOARCH)
+		log.Println("No release detected for current OS and arch")
+		return &CheckResult{Status: NoRelease, Current: current, Decision: PolicyDecision{Allowed: true}, Pending: pending, Reason: reason}, nil
+	}
+
+	latest := latestCandidate(cands)
+	minSupported := minSupportedVersion(rels, latest.version)
+	selected, rejected, reason := up.policy.selectCandidate(cands, current, up.versionYanked(rels, current), minSupported)
+
+	result := &CheckResult{Current: current, Decision: PolicyDecision{Allowed: true}, Pending: pending}
+	if rejected != nil {
+		r, err := up.newRelease(owner, repo, rels, rejected.release, rejected.asset, rejected.version)
+		if err != nil {
+			log.Println("Could not create rejected release", rejected.version, ":", err)
+		}
+		result.Decision = PolicyDecision{Allowed: false, Reason: reason, Rejected: r}
+	}
+
+	c := selected
+	switch {
+	case selected != nil && !selected.version.Equals(current) && minSupported != nil && current.LT(*minSupported):
+		result.Status = UpdateAvailable
+		result.Reason = fmt.Sprintf("current version %s is no longer supported (minimum supported version: %s)", current, *minSupported)
+	case selected != nil && !selected.version.Equals(current):
+		result.Status = UpdateAvailable
+		result.Reason = fmt.Sprintf("version %s was released", selected.version)
+	case rejected != nil && rejected.version.GT(current):
+		result.Status = Blocked
+		result.Reason = reason
+		c = latest
+	case latest.version.LT(current):
+		result.Status = Ahead
+		result.Reason = fmt.Sprintf("current version %s is newer than the latest release %s", current, latest.version)
+		c = latest
+	default:
+		result.Status = UpToDate
+		result.Reason = fmt.Sprintf("current version %s is the latest", current)
+	}
+
+	rel, err := up.newRelease(owner, repo, rels, c.release, c.asset, c.version)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == UpdateAvailable {
+		rel.Patch = findPatch(rel.Patches, current)
+		result.Changes = up.changes(owner, repo, rels, current, rel.Version)
+	}
+	result.Release = rel
+
+	log.Println("Current version", current, "is", result.Status, "against release", rel.Version)
+	return result, nil
+}
+
+// Check checks whether the current version can be updated without updating anything. 'slug' means
+// 'owner/name' formatted string and 'current' means the current version. Releases are selected in the same
+// way as UpdateCommand including the update policy. The returned result tells the status of the current
+// version and the candidate release.
+func (up *Updater) Check(current semver.Version, slug string) (*CheckResult, error) {
+	owner, repo, err := parseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := up.fetchAndCheck(owner, repo, current)
+	return result, err
+}
+
+// Check checks whether the current version can be updated to a release of the slug (owner/repo).
+// This function is a shortcut version of updater.Check() method.
+func Check(current semver.Version, slug string) (*CheckResult, error) {
+	return DefaultUpdater().Check(current, slug)
+}
@@ -0,0 +1,175 @@
+package selfupdate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func TestCheck(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{
+		binaryRelease("v1.2.0"),
+		binaryRelease("v1.2.1"),
+		binaryRelease("v2.0.0"),
+	})
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		what    string
+		policy  UpdatePolicy
+		current string
+		status  UpdateStatus
+		release string
+	}{
+		{"update available", UpdatePolicy{}, "1.2.0", UpdateAvailable, "2.0.0"},
+		{"update available with policy", UpdatePolicy{MaxBump: BumpMinor}, "1.2.0", UpdateAvailable, "1.2.1"},
+		{"up to date", UpdatePolicy{}, "2.0.0", UpToDate, "2.0.0"},
+		{"ahead", UpdatePolicy{}, "2.0.1", Ahead, "2.0.0"},
+		{"ahead allow downgrade", UpdatePolicy{AllowDowngrade: true}, "2.0.1", UpdateAvailable, "2.0.0"},
+		{"blocked", UpdatePolicy{MaxBump: BumpMinor}, "1.2.1", Blocked, "2.0.0"},
+		{"blocked not released version", UpdatePolicy{MaxBump: BumpMinor}, "1.5.0", Blocked, "2.0.0"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			up := newTestUpdater(t, srv, Config{Policy: tc.policy})
+			res, err := up.Check(semver.MustParse(tc.current), "owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tc.status {
+				t.Errorf("Wanted status %q but got %q", tc.status, res.Status)
+			}
+			if res.Current.String() != tc.current {
+				t.Errorf("Wanted current version %s but got %s", tc.current, res.Current)
+			}
+			if res.Release == nil {
+				t.Fatal("Candidate release should be set")
+			}
+			if res.Release.Version.String() != tc.release {
+				t.Errorf("Wanted candidate %s but got %s", tc.release, res.Release.Version)
+			}
+			if res.Release.AssetID == 0 {
+				t.Error("Candidate release should have its asset")
+			}
+		})
+	}
+}
+
+func TestCheckNoRelease(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{{tag: "v1.0.0", assets: []string{"foo_unknown_arch"}}})
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	res, err := up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != NoRelease {
+		t.Errorf("Wanted status %q but got %q", NoRelease, res.Status)
+	}
+	if res.Release != nil {
+		t.Error("Release should be nil when no release was found:", res.Release)
+	}
+}
+
+func TestUpdateCommandStatus(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.2.0"), binaryRelease("v2.0.0")})
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		current string
+		status  UpdateStatus
+		version string
+	}{
+		{"1.2.0", Updated, "2.0.0"},
+		{"2.0.0", UpToDate, "2.0.0"},
+		{"2.1.0", Ahead, "2.1.0"},
+	} {
+		t.Run(tc.current, func(t *testing.T) {
+			exe, cleanup := newTestExecutable(t, "v"+tc.current)
+			defer cleanup()
+
+			res, err := newTestUpdater(t, srv, Config{}).UpdateCommand(exe, semver.MustParse(tc.current), "owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tc.status {
+				t.Errorf("Wanted status %q but got %q", tc.status, res.Status)
+			}
+			if res.Version.String() != tc.version {
+				t.Errorf("Wanted version %s but got %s", tc.version, res.Version)
+			}
+			if got, want := readTestExecutable(t, exe), "v"+tc.version; got != want {
+				t.Errorf("Wanted executable %q but got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusString(t *testing.T) {
+	for s, want := range map[UpdateStatus]string{
+		UpToDate:          "up-to-date",
+		UpdateAvailable:   "update available",
+		Ahead:             "ahead",
+		NoRelease:         "no release",
+		Blocked:           "blocked",
+		Updated:           "updated",
+		Disabled:          "disabled",
+		Pinned:            "pinned",
+		UpdateStatus(100): "UpdateStatus(100)",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("Wanted %q but got %q", want, got)
+		}
+	}
+}
+
+func TestCheckReportsPendingRelease(t *testing.T) {
+	now := time.Now()
+	old := binaryRelease("v1.0.0")
+	old.published = now.Add(-48 * time.Hour)
+	soaking := binaryRelease("v1.1.0")
+	soaking.published = now.Add(-time.Hour)
+	srv, _ := newTestAPIServer(t, []testRelease{old, soaking})
+	defer srv.Close()
+
+	up := newTestUpdater(t, srv, Config{MinReleaseAge: 24 * time.Hour})
+	res, err := up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != UpToDate {
+		t.Errorf("Wanted status %q but got %q", UpToDate, res.Status)
+	}
+	if res.Pending == nil || res.Pending.Version.String() != "1.1.0" {
+		t.Fatalf("v1.1.0 should be pending: %+v", res.Pending)
+	}
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+	updated, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != UpToDate || updated.Pending == nil || updated.Pending.Version.String() != "1.1.0" {
+		t.Errorf("v1.1.0 should be pending in update result: %+v", updated)
+	}
+
+	up = newTestUpdater(t, srv, Config{MinReleaseAge: 72 * time.Hour})
+	res, err = up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != NoRelease || res.Pending == nil || res.Pending.Version.String() != "1.1.0" {
+		t.Errorf("Latest release should be pending when no release passes minimum release age: %+v", res)
+	}
+
+	up = newTestUpdater(t, srv, Config{})
+	res, err = up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != UpdateAvailable || res.Pending != nil {
+		t.Errorf("No release should be pending without minimum release age: %+v", res)
+	}
+}
@@ -0,0 +1,126 @@
+package selfupdate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// updateControls represents controls of self-update set by users via environment variables or a control file.
+type updateControls struct {
+	disabled bool
+	pin      *semver.Version
+	// source describes where the control was set (e.g. an environment variable name)
+	disabledBy string
+	pinnedBy   string
+}
+
+// envPrefix converts the tool name into a prefix of environment variables. For example, 'go-foo' is
+// converted into 'GO_FOO'.
+func envPrefix(tool string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, tool)
+}
+
+func parseSwitch(s string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on", "true", "1", "yes", "enable", "enabled":
+		return true, true
+	case "off", "false", "0", "no", "disable", "disabled":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// readControlFile reads a control file. Each line is 'key = value' or 'key: value'. Empty lines and lines
+// starting with '#' are ignored. When the file does not exist, it returns no entry without an error.
+func readControlFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("Failed to open control file %s: %s", path, err)
+	}
+	defer f.Close()
+
+	entries := map[string]string{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid line in control file %s: %q", path, line)
+		}
+		entries[strings.ToLower(strings.TrimSpace(line[:i]))] = strings.Trim(strings.TrimSpace(line[i+1:]), `"'`)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read control file %s: %s", path, err)
+	}
+	return entries, nil
+}
+
+// loadControls loads update controls for the tool. Environment variables take precedence over the control file.
+func (up *Updater) loadControls(tool string) (*updateControls, error) {
+	c := &updateControls{}
+
+	if up.controlFile != "" {
+		entries, err := readControlFile(up.controlFile)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := entries["selfupdate"]; ok {
+			enabled, ok := parseSwitch(v)
+			if !ok {
+				return nil, fmt.Errorf("Invalid value %q for 'selfupdate' in control file %s", v, up.controlFile)
+			}
+			c.disabled = !enabled
+			c.disabledBy = up.controlFile
+		}
+		if v, ok := entries["version_pin"]; ok && v != "" {
+			pin, err := semver.ParseTolerant(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid version %q for 'version_pin' in control file %s: %s", v, up.controlFile, err)
+			}
+			c.pin = &pin
+			c.pinnedBy = up.controlFile
+		}
+	}
+
+	prefix := envPrefix(tool)
+	name := prefix + "_SELFUPDATE"
+	if v := os.Getenv(name); v != "" {
+		enabled, ok := parseSwitch(v)
+		if !ok {
+			return nil, fmt.Errorf("Invalid value %q for $%s. It should be 'on' or 'off'", v, name)
+		}
+		c.disabled = !enabled
+		c.disabledBy = "$" + name
+	}
+	name = prefix + "_VERSION_PIN"
+	if v := os.Getenv(name); v != "" {
+		pin, err := semver.ParseTolerant(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version %q for $%s: %s", v, name, err)
+		}
+		c.pin = &pin
+		c.pinnedBy = "$" + name
+	}
+
+	return c, nil
+}
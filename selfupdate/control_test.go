@@ -0,0 +1,220 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestEnvPrefix(t *testing.T) {
+	for tool, want := range map[string]string{
+		"repo":                 "REPO",
+		"go-github-selfupdate": "GO_GITHUB_SELFUPDATE",
+		"Foo.Bar2":             "FOO_BAR2",
+	} {
+		if got := envPrefix(tool); got != want {
+			t.Errorf("Wanted %q for %q but got %q", want, tool, got)
+		}
+	}
+}
+
+func setenvForTest(t *testing.T, name, value string) func() {
+	saved, ok := os.LookupEnv(name)
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(name, saved)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func writeControlFile(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "selfupdate-control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "selfupdate.conf")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadControls(t *testing.T) {
+	path, cleanup := writeControlFile(t, "# Controls for CI\n\nselfupdate = off\nversion_pin: \"v1.4.2\"\n")
+	defer cleanup()
+
+	up := &Updater{controlFile: path}
+	c, err := up.loadControls("my-tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.disabled || c.disabledBy != path {
+		t.Errorf("Self-update should be disabled by control file: %+v", c)
+	}
+	if c.pin == nil || c.pin.String() != "1.4.2" || c.pinnedBy != path {
+		t.Errorf("Version should be pinned by control file: %+v", c)
+	}
+
+	defer setenvForTest(t, "MY_TOOL_SELFUPDATE", "on")()
+	defer setenvForTest(t, "MY_TOOL_VERSION_PIN", "1.5.0")()
+	c, err = up.loadControls("my-tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.disabled || c.disabledBy != "$MY_TOOL_SELFUPDATE" {
+		t.Errorf("Environment variable should take precedence over control file: %+v", c)
+	}
+	if c.pin == nil || c.pin.String() != "1.5.0" || c.pinnedBy != "$MY_TOOL_VERSION_PIN" {
+		t.Errorf("Environment variable should take precedence over control file: %+v", c)
+	}
+}
+
+func TestLoadControlsNotExistingFile(t *testing.T) {
+	up := &Updater{controlFile: filepath.Join("testdata", "not-existing.conf")}
+	c, err := up.loadControls("my-tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.disabled || c.pin != nil {
+		t.Errorf("Nothing should be controlled: %+v", c)
+	}
+}
+
+func TestLoadControlsError(t *testing.T) {
+	for _, tc := range []struct {
+		file string
+		env  string
+		val  string
+		want string
+	}{
+		{"selfupdate = maybe\n", "", "", "Invalid value \"maybe\" for 'selfupdate' in control file"},
+		{"version_pin = latest\n", "", "", "Invalid version \"latest\" for 'version_pin' in control file"},
+		{"no separator\n", "", "", "Invalid line in control file"},
+		{"", "MY_TOOL_SELFUPDATE", "never", "Invalid value \"never\" for $MY_TOOL_SELFUPDATE"},
+		{"", "MY_TOOL_VERSION_PIN", "foo", "Invalid version \"foo\" for $MY_TOOL_VERSION_PIN"},
+	} {
+		path, cleanup := writeControlFile(t, tc.file)
+		restore := func() {}
+		if tc.env != "" {
+			restore = setenvForTest(t, tc.env, tc.val)
+		}
+		_, err := (&Updater{controlFile: path}).loadControls("my-tool")
+		restore()
+		cleanup()
+		if err == nil {
+			t.Errorf("Error should occur for %q", tc.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Wanted error containing %q but got %q", tc.want, err)
+		}
+	}
+}
+
+func TestUpdateCommandDisabledByEnv(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), binaryRelease("v1.1.0")})
+	defer srv.Close()
+	defer setenvForTest(t, "REPO_SELFUPDATE", "off")()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	res, err := newTestUpdater(t, srv, Config{}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Disabled {
+		t.Errorf("Wanted status %q but got %q", Disabled, res.Status)
+	}
+	if res.Reason != "self-update is disabled by $REPO_SELFUPDATE" {
+		t.Errorf("Unexpected reason: %q", res.Reason)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be updated: %q", got)
+	}
+
+	defer setenvForTest(t, "MY_TOOL_SELFUPDATE", "off")()
+	res, err = newTestUpdater(t, srv, Config{ToolName: "my-tool"}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Disabled || !strings.Contains(res.Reason, "$MY_TOOL_SELFUPDATE") {
+		t.Errorf("Self-update should be disabled by tool name: %+v", res)
+	}
+}
+
+func TestDisabledWithoutNetwork(t *testing.T) {
+	defer setenvForTest(t, "REPO_SELFUPDATE", "off")()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	// The API is not accessed when self-update is disabled
+	up, err := NewUpdater(Config{EnterpriseBaseURL: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Disabled {
+		t.Errorf("Wanted status %q but got %q", Disabled, res.Status)
+	}
+	check, err := up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Status != Disabled {
+		t.Errorf("Wanted status %q but got %q", Disabled, check.Status)
+	}
+}
+
+func TestUpdateCommandPinnedVersion(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), binaryRelease("v1.1.0"), binaryRelease("v1.2.0")})
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.2.0")
+	defer cleanup()
+	path, cleanupFile := writeControlFile(t, "version_pin = 1.1.0\n")
+	defer cleanupFile()
+	up := newTestUpdater(t, srv, Config{ControlFile: path})
+
+	res, err := up.UpdateCommand(exe, semver.MustParse("1.2.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Updated || res.Version.String() != "1.1.0" {
+		t.Errorf("Pinned version should be installed even if it is older: %+v", res)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated to pinned version: %q", got)
+	}
+
+	res, err = up.UpdateCommand(exe, semver.MustParse("1.1.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Pinned || res.Reason != "version is pinned to 1.1.0 by "+path {
+		t.Errorf("Update should be skipped by pin: %+v", res)
+	}
+
+	defer setenvForTest(t, "REPO_VERSION_PIN", "9.9.9")()
+	res, err = up.UpdateCommand(exe, semver.MustParse("1.1.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != NoRelease || !strings.Contains(res.Reason, "release of version 9.9.9 pinned by $REPO_VERSION_PIN was not found") {
+		t.Errorf("Pinned version which does not exist should not be found: %+v", res)
+	}
+}
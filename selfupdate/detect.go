@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/blang/semver"
@@ -12,28 +14,14 @@ import (
 
 var reVersion = regexp.MustCompile(`\d+\.\d+\.\d+`)
 
-func findAssetFromRelease(rel *github.RepositoryRelease,
-	suffixes []string, targetVersion string, filters []*regexp.Regexp) (*github.ReleaseAsset, semver.Version, bool) {
-
-	if targetVersion != "" && targetVersion != rel.GetTagName() {
-		log.Println("Skip", rel.GetTagName(), "not matching to specified version", targetVersion)
-		return nil, semver.Version{}, false
-	}
-
-	if targetVersion == "" && rel.GetDraft() {
-		log.Println("Skip draft version", rel.GetTagName())
-		return nil, semver.Version{}, false
-	}
-	if targetVersion == "" && rel.GetPrerelease() {
-		log.Println("Skip pre-release version", rel.GetTagName())
-		return nil, semver.Version{}, false
-	}
-
-	verText := rel.GetTagName()
+// parseTagVersion parses a semantic version in the tag name. Prefix of the version such as 'v' or
+// 'release-' is stripped.
+func parseTagVersion(tag string) (semver.Version, bool) {
+	verText := tag
 	indices := reVersion.FindStringIndex(verText)
 	if indices == nil {
 		log.Println("Skip version not adopting semver", verText)
-		return nil, semver.Version{}, false
+		return semver.Version{}, false
 	}
 	if indices[0] > 0 {
 		log.Println("Strip prefix of version", verText[:indices[0]], "from", verText)
@@ -45,6 +33,79 @@ func findAssetFromRelease(rel *github.RepositoryRelease,
 	ver, err := semver.Make(verText)
 	if err != nil {
 		log.Println("Failed to parse a semantic version", verText)
+		return semver.Version{}, false
+	}
+
+	return ver, true
+}
+
+// rePartialVersion matches a version which omits its patch or minor version such as '1.2', 'v1.2.x' or '1.x'.
+var rePartialVersion = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.[xX*])?$`)
+
+// versionQuery is a version specified by a user to detect a release.
+type versionQuery struct {
+	tag string
+	// version is set when the query is a full semantic version such as '1.2.3' or 'v1.2.3'.
+	version *semver.Version
+	// partial is true when the query omits its patch or minor version. The latest release matching the
+	// major (and minor) version is detected.
+	partial  bool
+	major    uint64
+	minor    uint64
+	hasMinor bool
+}
+
+func parseVersionQuery(query string) *versionQuery {
+	q := &versionQuery{tag: query}
+	if m := rePartialVersion.FindStringSubmatch(query); m != nil {
+		q.partial = true
+		q.major, _ = strconv.ParseUint(m[1], 10, 64)
+		if m[2] != "" {
+			q.minor, _ = strconv.ParseUint(m[2], 10, 64)
+			q.hasMinor = true
+		}
+		return q
+	}
+	if i := reVersion.FindStringIndex(query); i != nil {
+		if v, err := semver.Make(query[i[0]:]); err == nil {
+			q.version = &v
+		}
+	}
+	return q
+}
+
+// exact returns true when the query specifies a single release. A partial version and an empty query
+// are not exact because the latest release among the matching releases is detected.
+func (q *versionQuery) exact() bool {
+	return q.tag != "" && !q.partial
+}
+
+// match returns true when the release of the tag and the version matches the query. A full semantic version
+// is compared as a version so that '1.2.3' matches tags such as 'v1.2.3' or 'release-1.2.3'. Otherwise
+// the query must be the same as the tag name.
+func (q *versionQuery) match(tag string, ver semver.Version) bool {
+	switch {
+	case q.tag == "" || q.tag == tag:
+		return true
+	case q.partial:
+		return ver.Major == q.major && (!q.hasMinor || ver.Minor == q.minor)
+	case q.version != nil:
+		return ver.Equals(*q.version)
+	default:
+		return false
+	}
+}
+
+func findAssetFromRelease(rel *github.RepositoryRelease,
+	suffixes []string, targetVersion string, filters []*regexp.Regexp) (*github.ReleaseAsset, semver.Version, bool) {
+
+	ver, ok := parseTagVersion(rel.GetTagName())
+	if !ok {
+		return nil, semver.Version{}, false
+	}
+
+	if !parseVersionQuery(targetVersion).match(rel.GetTagName(), ver) {
+		log.Println("Skip", rel.GetTagName(), "not matching to specified version", targetVersion)
 		return nil, semver.Version{}, false
 	}
 
@@ -87,10 +148,24 @@ func findValidationAsset(rel *github.RepositoryRelease, validationName string) (
 	return nil, false
 }
 
-func findReleaseAndAsset(rels []*github.RepositoryRelease,
-	targetVersion string,
-	filters []*regexp.Regexp) (*github.RepositoryRelease, *github.ReleaseAsset, semver.Version, bool) {
-	// Generate candidates
+// findValidationAssetFor finds the validation asset for the asset named 'name' in the release. It is named as
+// the asset name with the suffix of the validator unless the validator implements ValidationAssetSelector.
+// The returned string is the name of the validation asset to look for, used in error messages.
+func (up *Updater) findValidationAssetFor(rel *github.RepositoryRelease, name string) (*github.ReleaseAsset, string, bool) {
+	if s, ok := up.validator.(ValidationAssetSelector); ok {
+		for _, asset := range rel.Assets {
+			if asset.GetName() != name && s.MatchValidationAsset(name, asset.GetName()) {
+				return asset, asset.GetName(), true
+			}
+		}
+		return nil, "validation asset for " + name, false
+	}
+	validationName := name + up.validator.Suffix()
+	asset, ok := findValidationAsset(rel, validationName)
+	return asset, validationName, ok
+}
+
+func assetSuffixes() []string {
 	suffixes := make([]string, 0, 2*7*2)
 	for _, sep := range []rune{'_', '-'} {
 		for _, ext := range []string{".zip", ".tar.gz", ".tgz", ".gzip", ".gz", ".tar.xz", ".xz", ""} {
@@ -102,37 +177,188 @@ func findReleaseAndAsset(rels []*github.RepositoryRelease,
 			}
 		}
 	}
+	return suffixes
+}
+
+// releaseCandidate is a release which has a suitable asset for current OS and arch.
+type releaseCandidate struct {
+	release *github.RepositoryRelease
+	asset   *github.ReleaseAsset
+	version semver.Version
+}
+
+// skipRelease returns true when the release should not be a candidate of detection.
+// When a single version is specified explicitly, drafts and pre-releases are not skipped.
+func (up *Updater) skipRelease(rel *github.RepositoryRelease, exact bool, prerelease bool) bool {
+	if exact {
+		return false
+	}
+	if rel.GetDraft() && !up.drafts {
+		log.Println("Skip draft version", rel.GetTagName())
+		return true
+	}
+	if !prerelease && rel.GetPrerelease() {
+		log.Println("Skip pre-release version", rel.GetTagName())
+		return true
+	}
+	return false
+}
 
-	var ver semver.Version
-	var asset *github.ReleaseAsset
-	var release *github.RepositoryRelease
+// findCandidates collects all releases which have a suitable asset for current OS and arch.
+// Order of the returned candidates is the same as the given releases. Releases published more recently than
+// the minimum release age are not candidates. They are returned separately as the second return value.
+func (up *Updater) findCandidates(rels []*github.RepositoryRelease, targetVersion string, prerelease bool) ([]releaseCandidate, []releaseCandidate) {
+	suffixes := assetSuffixes()
+	exact := parseVersionQuery(targetVersion).exact()
+	cands := make([]releaseCandidate, 0, len(rels))
+	soaking := []releaseCandidate{}
+	for _, rel := range rels {
+		if up.skipRelease(rel, exact, prerelease) {
+			continue
+		}
+		a, v, ok := findAssetFromRelease(rel, suffixes, targetVersion, up.filters)
+		if !ok {
+			continue
+		}
+		if !exact && up.isYanked(rel, v) {
+			log.Println("Skip yanked version", rel.GetTagName())
+			continue
+		}
+		if !exact && !up.rolledOut(rel, v) {
+			log.Println("Skip version", rel.GetTagName(), "not rolled out to this machine yet")
+			continue
+		}
+		if !exact && up.soaking(rel) {
+			log.Println("Skip version", rel.GetTagName(), "published at", rel.GetPublishedAt().Time, "because it is newer than minimum release age", up.minReleaseAge)
+			soaking = append(soaking, releaseCandidate{rel, a, v})
+			continue
+		}
+		cands = append(cands, releaseCandidate{rel, a, v})
+	}
+	return cands, soaking
+}
+
+// soaking returns true when the release was published more recently than the minimum release age.
+func (up *Updater) soaking(rel *github.RepositoryRelease) bool {
+	if up.minReleaseAge <= 0 || rel.PublishedAt == nil {
+		return false
+	}
+	return timeNow().Sub(rel.GetPublishedAt().Time) < up.minReleaseAge
+}
+
+// pendingCandidate returns the latest candidate pending for the minimum release age which would be selected
+// instead of the latest candidate. It returns nil when no such candidate exists.
+func (up *Updater) pendingCandidate(soaking []releaseCandidate, latest *releaseCandidate) *releaseCandidate {
+	pending := latestCandidate(soaking)
+	if pending == nil || latest != nil && pending.version.LTE(latest.version) {
+		return nil
+	}
+	log.Println("Version", pending.version, "would be the latest but it is pending soak until", pending.release.GetPublishedAt().Time.Add(up.minReleaseAge))
+	return pending
+}
+
+// latestCandidate returns the candidate of the latest version. It returns nil when no candidate is given.
+func latestCandidate(cands []releaseCandidate) *releaseCandidate {
+	var latest *releaseCandidate
 
 	// Find the latest version from the list of releases.
 	// Returned list from GitHub API is in the order of the date when created.
 	//   ref: https://github.com/rhysd/go-github-selfupdate/issues/11
-	for _, rel := range rels {
-		if a, v, ok := findAssetFromRelease(rel, suffixes, targetVersion, filters); ok {
-			// Note: any version with suffix is less than any version without suffix.
-			// e.g. 0.0.1 > 0.0.1-beta
-			if release == nil || v.GTE(ver) {
-				ver = v
-				asset = a
-				release = rel
-			}
+	for i := range cands {
+		// Note: any version with suffix is less than any version without suffix.
+		// e.g. 0.0.1 > 0.0.1-beta
+		if latest == nil || cands[i].version.GTE(latest.version) {
+			latest = &cands[i]
 		}
 	}
 
-	if release == nil {
+	return latest
+}
+
+func (up *Updater) findReleaseAndAsset(rels []*github.RepositoryRelease,
+	targetVersion string) (*github.RepositoryRelease, *github.ReleaseAsset, semver.Version, bool) {
+	cands, soaking := up.findCandidates(rels, targetVersion, false)
+	latest := latestCandidate(cands)
+	up.pendingCandidate(soaking, latest)
+	if latest == nil {
 		log.Println("Could not find any release for", runtime.GOOS, "and", runtime.GOARCH)
 		return nil, nil, semver.Version{}, false
 	}
 
-	return release, asset, ver, true
+	return latest.release, latest.asset, latest.version, true
+}
+
+func parseSlug(slug string) (string, string, error) {
+	repo := strings.Split(slug, "/")
+	if len(repo) != 2 || repo[0] == "" || repo[1] == "" {
+		return "", "", fmt.Errorf("Invalid slug format. It should be 'owner/name': %s", slug)
+	}
+	return repo[0], repo[1], nil
+}
+
+// fetchReleases fetches all releases of the repository via GitHub API following pagination. When the
+// repository or its releases are not found, it returns no release without an error.
+func (up *Updater) fetchReleases(owner, repo string) ([]*github.RepositoryRelease, error) {
+	var all []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		rels, res, err := up.api.Repositories.ListReleases(up.apiCtx, owner, repo, opts)
+		if err != nil {
+			log.Println("API returned an error response:", err)
+			if res != nil && res.StatusCode == 404 {
+				// 404 means repository not found or release not found. It's not an error here.
+				log.Println("API returned 404. Repository or release not found")
+				return nil, nil
+			}
+			return nil, err
+		}
+		all = append(all, rels...)
+		if res.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = res.NextPage
+	}
+}
+
+// newRelease creates a Release instance from the release and its asset returned from GitHub API.
+// When a validator is set, the validation asset for the asset is also looked up.
+// Metadata of the releases up to the release is also considered to calculate its minimum supported version.
+func (up *Updater) newRelease(owner, repo string, rels []*github.RepositoryRelease, rel *github.RepositoryRelease, asset *github.ReleaseAsset, ver semver.Version) (*Release, error) {
+	publishedAt := rel.GetPublishedAt().Time
+	release := &Release{
+		Version:             ver,
+		AssetURL:            asset.GetBrowserDownloadURL(),
+		AssetName:           asset.GetName(),
+		AssetByteSize:       asset.GetSize(),
+		AssetID:             asset.GetID(),
+		ValidationAssetID:   -1,
+		TagName:             rel.GetTagName(),
+		URL:                 rel.GetHTMLURL(),
+		ReleaseNotes:        rel.GetBody(),
+		Name:                rel.GetName(),
+		PublishedAt:         &publishedAt,
+		RepoOwner:           owner,
+		RepoName:            repo,
+		Draft:               rel.GetDraft(),
+		Critical:            parseReleaseMetadata(rel.GetBody()).bool("critical"),
+		MinSupportedVersion: minSupportedVersion(rels, ver),
+		Patches:             up.findPatches(rel, asset),
+	}
+
+	if up.validator != nil {
+		validationAsset, validationName, ok := up.findValidationAssetFor(rel, asset.GetName())
+		if !ok {
+			return nil, fmt.Errorf("Failed finding validation file %q", validationName)
+		}
+		release.ValidationAssetID = validationAsset.GetID()
+	}
+
+	return release, nil
 }
 
 // DetectLatest tries to get the latest version of the repository on GitHub. 'slug' means 'owner/name' formatted string.
 // It fetches releases information from GitHub API and find out the latest release with matching the tag names and asset names.
-// Drafts and pre-releases are ignored. Assets would be suffixed by the OS name and the arch name such as 'foo_linux_amd64'
+// Drafts and pre-releases are ignored unless Config.IncludeDrafts is set. Assets would be suffixed by the OS name and the arch name such as 'foo_linux_amd64'
 // where 'foo' is a command name. '-' can also be used as a separator. File can be compressed with zip, gzip, zxip, tar&zip or tar&zxip.
 // So the asset can have a file extension for the corresponding compression format such as '.zip'.
 // On Windows, '.exe' also can be contained such as 'foo_windows_amd64.exe.zip'.
@@ -141,57 +367,82 @@ func (up *Updater) DetectLatest(slug string) (release *Release, found bool, err
 }
 
 // DetectVersion tries to get the given version of the repository on Github. `slug` means `owner/name` formatted string.
-// And version indicates the required version.
+// And version indicates the required version. The version is compared as a semantic version so '1.2.3' matches
+// tags such as 'v1.2.3' and 'release-1.2.3'. A version which is not a semantic version must be the same as the tag
+// name. When the patch version or the minor version is omitted like '1.2' or '1' ('1.2.x' and '1.x' are also
+// accepted), the latest patch release of 1.2 or the latest minor release of 1 is detected with the same rules as
+// DetectLatest.
 func (up *Updater) DetectVersion(slug string, version string) (release *Release, found bool, err error) {
-	repo := strings.Split(slug, "/")
-	if len(repo) != 2 || repo[0] == "" || repo[1] == "" {
-		return nil, false, fmt.Errorf("Invalid slug format. It should be 'owner/name': %s", slug)
+	owner, repo, err := parseSlug(slug)
+	if err != nil {
+		return nil, false, err
 	}
 
-	rels, res, err := up.api.Repositories.ListReleases(up.apiCtx, repo[0], repo[1], nil)
+	rels, err := up.fetchReleases(owner, repo)
 	if err != nil {
-		log.Println("API returned an error response:", err)
-		if res != nil && res.StatusCode == 404 {
-			// 404 means repository not found or release not found. It's not an error here.
-			err = nil
-			log.Println("API returned 404. Repository or release not found")
-		}
 		return nil, false, err
 	}
 
-	rel, asset, ver, found := findReleaseAndAsset(rels, version, up.filters)
+	return up.detectFromReleases(owner, repo, rels, version)
+}
+
+func (up *Updater) detectFromReleases(owner, repo string, rels []*github.RepositoryRelease, version string) (*Release, bool, error) {
+	rel, asset, ver, found := up.findReleaseAndAsset(rels, version)
 	if !found {
 		return nil, false, nil
 	}
 
-	url := asset.GetBrowserDownloadURL()
-	log.Println("Successfully fetched the latest release. tag:", rel.GetTagName(), ", name:", rel.GetName(), ", URL:", rel.GetURL(), ", Asset:", url)
+	log.Println("Successfully fetched the latest release. tag:", rel.GetTagName(), ", name:", rel.GetName(), ", URL:", rel.GetURL(), ", Asset:", asset.GetBrowserDownloadURL())
 
-	publishedAt := rel.GetPublishedAt().Time
-	release = &Release{
-		ver,
-		url,
-		asset.GetSize(),
-		asset.GetID(),
-		-1,
-		rel.GetHTMLURL(),
-		rel.GetBody(),
-		rel.GetName(),
-		&publishedAt,
-		repo[0],
-		repo[1],
+	release, err := up.newRelease(owner, repo, rels, rel, asset, ver)
+	if err != nil {
+		return nil, false, err
 	}
 
-	if up.validator != nil {
-		validationName := asset.GetName() + up.validator.Suffix()
-		validationAsset, ok := findValidationAsset(rel, validationName)
-		if !ok {
-			return nil, false, fmt.Errorf("Failed finding validation file %q", validationName)
+	return release, true, nil
+}
+
+// ListOptions represents options for listing releases with ListReleases.
+type ListOptions struct {
+	// Prerelease makes pre-releases listed as well. By default, pre-releases are ignored as DetectLatest does.
+	Prerelease bool
+}
+
+// ListReleases returns all releases of the repository which have a suitable asset for current OS and arch.
+// 'slug' means 'owner/name' formatted string. Releases are selected with the same rules as DetectLatest
+// and are sorted from the newest version to the oldest one. When a validator is set, releases which
+// don't have a validation asset are omitted. 'opts' can be nil.
+func (up *Updater) ListReleases(slug string, opts *ListOptions) ([]*Release, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	owner, repo, err := parseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	rels, err := up.fetchReleases(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	cands, _ := up.findCandidates(rels, "", opts.Prerelease)
+	releases := make([]*Release, 0, len(cands))
+	for _, c := range cands {
+		r, err := up.newRelease(owner, repo, rels, c.release, c.asset, c.version)
+		if err != nil {
+			log.Println("Skip", c.release.GetTagName(), "because validation asset is missing:", err)
+			continue
 		}
-		release.ValidationAssetID = validationAsset.GetID()
+		releases = append(releases, r)
 	}
 
-	return release, true, nil
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].Version.GT(releases[j].Version)
+	})
+
+	return releases, nil
 }
 
 // DetectLatest detects the latest release of the slug (owner/repo).
@@ -204,3 +455,9 @@ func DetectLatest(slug string) (*Release, bool, error) {
 func DetectVersion(slug string, version string) (*Release, bool, error) {
 	return DefaultUpdater().DetectVersion(slug, version)
 }
+
+// ListReleases lists all releases of the slug (owner/repo) which are available for current OS and arch.
+// This function is a shortcut version of updater.ListReleases() method.
+func ListReleases(slug string, opts *ListOptions) ([]*Release, error) {
+	return DefaultUpdater().ListReleases(slug, opts)
+}
@@ -1,11 +1,17 @@
 package selfupdate
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/google/go-github/v30/github"
@@ -455,3 +461,300 @@ func TestFindReleaseAndAsset(t *testing.T) {
 	}
 
 }
+
+type testRelease struct {
+	tag        string
+	draft      bool
+	prerelease bool
+	body       string
+	assets     []string
+	// files maps asset names to their contents served by the fake API server
+	files     map[string][]byte
+	published time.Time
+}
+
+func (r *testRelease) toGitHub(id int64) *github.RepositoryRelease {
+	rel := &github.RepositoryRelease{
+		ID:         github.Int64(id),
+		TagName:    github.String(r.tag),
+		Name:       github.String(r.tag),
+		Body:       github.String(r.body),
+		Draft:      github.Bool(r.draft),
+		Prerelease: github.Bool(r.prerelease),
+		HTMLURL:    github.String("https://github.com/owner/repo/releases/tag/" + r.tag),
+	}
+	if !r.published.IsZero() {
+		rel.PublishedAt = &github.Timestamp{Time: r.published}
+	}
+	for i, name := range r.assets {
+		size := 1024
+		if b, ok := r.files[name]; ok {
+			size = len(b)
+		}
+		rel.Assets = append(rel.Assets, &github.ReleaseAsset{
+			ID:                 github.Int64(id*100 + int64(i)),
+			Name:               github.String(name),
+			Size:               github.Int(size),
+			BrowserDownloadURL: github.String(fmt.Sprintf("https://github.com/owner/repo/releases/download/%s/%s", r.tag, name)),
+		})
+	}
+	return rel
+}
+
+func platformAsset(name string) string {
+	return fmt.Sprintf("%s_%s_%s.zip", name, runtime.GOOS, runtime.GOARCH)
+}
+
+// platformBinary returns a name of an uncompressed executable asset for current OS and arch.
+func platformBinary(name string) string {
+	return fmt.Sprintf("%s_%s_%s", name, runtime.GOOS, runtime.GOARCH)
+}
+
+// binaryRelease returns a test release which has an uncompressed executable asset whose content is the tag.
+func binaryRelease(tag string) testRelease {
+	name := platformBinary("foo")
+	return testRelease{tag: tag, assets: []string{name}, files: map[string][]byte{name: []byte(tag)}}
+}
+
+// newTestAPIServer starts a fake GitHub API server which serves the given releases for 'owner/repo'.
+// The releases are paginated with 'page' and 'per_page' query parameters like GitHub API.
+// Additional handlers can be registered to the returned mux.
+func newTestAPIServer(t *testing.T, rels []testRelease) (*httptest.Server, *http.ServeMux) {
+	ghRels := make([]*github.RepositoryRelease, 0, len(rels))
+	for i := range rels {
+		ghRels = append(ghRels, rels[i].toGitHub(int64(i+1)))
+	}
+	files := map[string][]byte{}
+	for i, r := range rels {
+		for j, name := range r.assets {
+			if b, ok := r.files[name]; ok {
+				files[fmt.Sprintf("/api/v3/repos/owner/repo/releases/assets/%d", int64(i+1)*100+int64(j))] = b
+			}
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		page, perPage := 1, 30
+		if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+			page = p
+		}
+		if p, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && p > 0 {
+			perPage = p
+		}
+		start, end := (page-1)*perPage, page*perPage
+		if start > len(ghRels) {
+			start = len(ghRels)
+		}
+		if end >= len(ghRels) {
+			end = len(ghRels)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%d&per_page=%d>; rel="next"`, r.Host, r.URL.Path, page+1, perPage))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ghRels[start:end]); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/", func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(b)
+	})
+	return httptest.NewServer(mux), mux
+}
+
+func newTestUpdater(t *testing.T, srv *httptest.Server, config Config) *Updater {
+	config.APIToken = "test-token"
+	config.EnterpriseBaseURL = srv.URL
+	up, err := NewUpdater(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return up
+}
+
+func TestListReleases(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.0.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.3.0-beta", prerelease: true, assets: []string{platformAsset("foo")}},
+		{tag: "v2.0.0", draft: true, assets: []string{platformAsset("foo")}},
+		{tag: "v1.4.0", assets: []string{"foo_unknown_arch.zip"}},
+		{tag: "nightly", assets: []string{platformAsset("foo")}},
+	})
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	for _, tc := range []struct {
+		what string
+		opts *ListOptions
+		want []string
+	}{
+		{"default", nil, []string{"1.2.0", "1.1.0", "1.0.0"}},
+		{"prerelease", &ListOptions{Prerelease: true}, []string{"1.3.0-beta", "1.2.0", "1.1.0", "1.0.0"}},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			rels, err := up.ListReleases("owner/repo", tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rels) != len(tc.want) {
+				t.Fatalf("Wanted %d releases but got %d", len(tc.want), len(rels))
+			}
+			for i, r := range rels {
+				if r.Version.String() != tc.want[i] {
+					t.Errorf("Wanted %s at %d but got %s", tc.want[i], i, r.Version)
+				}
+				if r.AssetID == 0 {
+					t.Error("Asset ID is unexpectedly zero for", r.Version)
+				}
+				if r.ValidationAssetID != -1 {
+					t.Error("Validation asset ID should be -1 without validator:", r.ValidationAssetID)
+				}
+				if r.RepoOwner != "owner" || r.RepoName != "repo" {
+					t.Error("Unexpected repository:", r.RepoOwner, r.RepoName)
+				}
+			}
+		})
+	}
+}
+
+func TestListReleasesPaginated(t *testing.T) {
+	rels := make([]testRelease, 0, 250)
+	for i := 250; i > 0; i-- {
+		rels = append(rels, binaryRelease(fmt.Sprintf("v1.0.%d", i)))
+	}
+	srv, _ := newTestAPIServer(t, rels)
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	got, err := up.ListReleases("owner/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 250 {
+		t.Fatalf("Wanted all 250 releases across pages but got %d", len(got))
+	}
+	if v := got[len(got)-1].Version.String(); v != "1.0.1" {
+		t.Error("Oldest release on the last page should be listed:", v)
+	}
+}
+
+func TestListReleasesWithValidator(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.0.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", assets: []string{platformAsset("foo"), platformAsset("foo") + ".sha256"}},
+	})
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+
+	rels, err := up.ListReleases("owner/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 1 {
+		t.Fatalf("Release without validation asset should be omitted: %v", rels)
+	}
+	if rels[0].ValidationAssetID != 201 {
+		t.Error("Unexpected validation asset ID:", rels[0].ValidationAssetID)
+	}
+}
+
+func TestListReleasesInvalidSlug(t *testing.T) {
+	_, err := DefaultUpdater().ListReleases("foo", nil)
+	if err == nil || !strings.Contains(err.Error(), "Invalid slug format") {
+		t.Fatal("Invalid slug should cause an error:", err)
+	}
+}
+
+func TestDetectLatestWithMinReleaseAge(t *testing.T) {
+	now := time.Now()
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.0.0", published: now.Add(-72 * time.Hour), assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", published: now.Add(-30 * time.Hour), assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", published: now.Add(-30 * time.Minute), assets: []string{platformAsset("foo")}},
+	})
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		age  time.Duration
+		want string
+	}{
+		{0, "1.2.0"},
+		{time.Hour, "1.1.0"},
+		{48 * time.Hour, "1.0.0"},
+	} {
+		up := newTestUpdater(t, srv, Config{MinReleaseAge: tc.age})
+		rel, ok, err := up.DetectLatest("owner/repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("Release was not found with minimum release age", tc.age)
+		}
+		if rel.Version.String() != tc.want {
+			t.Errorf("Wanted %s with minimum release age %s but got %s", tc.want, tc.age, rel.Version)
+		}
+	}
+
+	up := newTestUpdater(t, srv, Config{MinReleaseAge: 100 * time.Hour})
+	if _, ok, err := up.DetectLatest("owner/repo"); err != nil || ok {
+		t.Fatal("No release should be found when all releases are too new:", ok, err)
+	}
+}
+
+func TestDetectVersionNormalized(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.1.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", assets: []string{platformAsset("foo")}},
+		{tag: "release-1.2.3", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.4", prerelease: true, assets: []string{platformAsset("foo")}},
+		{tag: "v1.3.1", assets: []string{platformAsset("foo")}},
+		{tag: "v1.4.0", draft: true, assets: []string{platformAsset("foo")}},
+		{tag: "v2.0.0", assets: []string{platformAsset("foo")}},
+	})
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	for _, tc := range []struct {
+		version string
+		want    string
+	}{
+		{"1.2.0", "1.2.0"},
+		{"v1.2.0", "1.2.0"},
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"release-1.2.3", "1.2.3"},
+		{"1.2.4", "1.2.4"},
+		{"v1.4.0", "1.4.0"},
+		{"1.2", "1.2.3"},
+		{"v1.2", "1.2.3"},
+		{"1.2.x", "1.2.3"},
+		{"1", "1.3.1"},
+		{"1.x", "1.3.1"},
+		{"v2", "2.0.0"},
+	} {
+		rel, ok, err := up.DetectVersion("owner/repo", tc.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("Release for %q was not found", tc.version)
+			continue
+		}
+		if rel.Version.String() != tc.want {
+			t.Errorf("Wanted %s for %q but got %s", tc.want, tc.version, rel.Version)
+		}
+	}
+
+	for _, v := range []string{"1.2.5", "1.5", "3", "v1.2.0-beta", "foo"} {
+		if rel, ok, err := up.DetectVersion("owner/repo", v); err != nil || ok {
+			t.Errorf("Release for %q should not be found: %v, %v", v, rel, err)
+		}
+	}
+}
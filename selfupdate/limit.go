@@ -0,0 +1,72 @@
+package selfupdate
+
+import (
+	"fmt"
+	"io"
+)
+
+// SizeLimitError is an error returned when the size of a downloaded asset or an extracted executable exceeds
+// the limit configured with Config.MaxDownloadBytes or Config.MaxExecutableBytes.
+type SizeLimitError struct {
+	// What describes what exceeded the limit. It is "asset" or "executable".
+	What string
+	// Limit is the configured maximum size in bytes.
+	Limit int64
+	// Size is the size in bytes when it was known before reading. It is -1 when the limit was exceeded
+	// while reading.
+	Size int64
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("Size of %s exceeds the limit of %d bytes", e.What, e.Limit)
+	}
+	return fmt.Sprintf("Size of %s (%d bytes) exceeds the limit of %d bytes", e.What, e.Size, e.Limit)
+}
+
+// sizeLimitReader is a reader which fails with SizeLimitError when more bytes than the limit are read.
+type sizeLimitReader struct {
+	r     io.Reader
+	what  string
+	limit int64
+	read  int64
+}
+
+func (l *sizeLimitReader) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, &SizeLimitError{What: l.what, Limit: l.limit, Size: -1}
+	}
+	return n, err
+}
+
+// checkSizeLimit returns SizeLimitError when the size known before reading exceeds the limit. Zero or
+// negative limit means no limit.
+func checkSizeLimit(what string, size, limit int64) error {
+	if limit > 0 && size > limit {
+		return &SizeLimitError{What: what, Limit: limit, Size: size}
+	}
+	return nil
+}
+
+// limitSize wraps the reader to limit its size. 'size' is the size known before reading, or -1. When it
+// already exceeds the limit, an error is returned immediately. Zero or negative limit means no limit.
+func limitSize(r io.Reader, what string, size, limit int64) (io.Reader, error) {
+	if err := checkSizeLimit(what, size, limit); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return r, nil
+	}
+	return &sizeLimitReader{r: r, what: what, limit: limit}, nil
+}
+
+// checkDownloadSize checks the number of downloaded bytes against the expected size of the asset to detect
+// a truncated download. Negative 'expected' means that the size is unknown.
+func checkDownloadSize(downloaded, expected int64) error {
+	if expected >= 0 && downloaded != expected {
+		return fmt.Errorf("Size of downloaded asset %d bytes does not match to the size of asset %d bytes", downloaded, expected)
+	}
+	return nil
+}
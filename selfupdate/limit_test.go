@@ -0,0 +1,150 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestLimitSize(t *testing.T) {
+	r, err := limitSize(strings.NewReader("hello"), "asset", -1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadAll(r); err != nil || string(b) != "hello" {
+		t.Errorf("Content within the limit should be read: %q, %v", b, err)
+	}
+
+	r, err = limitSize(strings.NewReader("hello!"), "asset", -1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(r)
+	e, ok := err.(*SizeLimitError)
+	if !ok {
+		t.Fatalf("SizeLimitError should be returned but got %T: %v", err, err)
+	}
+	if e.What != "asset" || e.Limit != 5 || e.Size != -1 {
+		t.Errorf("Unexpected error: %+v", e)
+	}
+	if e.Error() != "Size of asset exceeds the limit of 5 bytes" {
+		t.Errorf("Unexpected message: %q", e.Error())
+	}
+
+	_, err = limitSize(strings.NewReader("hello!"), "executable", 6, 5)
+	if err == nil {
+		t.Fatal("Error should occur when known size exceeds the limit")
+	}
+	if err.Error() != "Size of executable (6 bytes) exceeds the limit of 5 bytes" {
+		t.Errorf("Unexpected message: %q", err.Error())
+	}
+
+	r, err = limitSize(strings.NewReader("hello!"), "asset", 6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(r); string(b) != "hello!" {
+		t.Errorf("Zero limit should mean no limit: %q", b)
+	}
+}
+
+func TestUpdateCommandSizeLimits(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	size := int64(len(rel.files[platformAsset("foo")]))
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		what   string
+		config Config
+		want   SizeLimitError
+	}{
+		{
+			what:   "asset",
+			config: Config{MaxDownloadBytes: size - 1},
+			want:   SizeLimitError{What: "asset", Limit: size - 1, Size: size},
+		},
+		{
+			what:   "executable",
+			config: Config{MaxExecutableBytes: 3},
+			want:   SizeLimitError{What: "executable", Limit: 3, Size: -1},
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			exe, cleanup := newTestExecutable(t, "v1.0.0")
+			defer cleanup()
+
+			up := newTestUpdater(t, srv, tc.config)
+			_, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+			e, ok := err.(*SizeLimitError)
+			if !ok {
+				t.Fatalf("SizeLimitError should be returned but got %T: %v", err, err)
+			}
+			if *e != tc.want {
+				t.Errorf("Wanted %+v but got %+v", tc.want, *e)
+			}
+			if got := readTestExecutable(t, exe); got != "v1.0.0" {
+				t.Errorf("Executable should not be updated: %q", got)
+			}
+		})
+	}
+}
+
+func TestUpdateCommandTruncatedDownload(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	asset := platformAsset("foo")
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		b := rel.files[asset]
+		w.Write(b[:len(b)/2])
+	})
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{})
+	_, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err == nil {
+		t.Fatal("Error should occur for truncated download")
+	}
+	if !strings.Contains(err.Error(), "does not match to the size of asset") {
+		t.Error("Unexpected error:", err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be updated: %q", got)
+	}
+}
+
+func TestUpdateToURLSizeLimitWithoutContentLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1.1.0"))
+		// Flushing makes the response chunked so that Content-Length is unknown
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up, err := NewUpdater(Config{MaxDownloadBytes: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = up.UpdateToURL(srv.URL+"/foo", exe)
+	e, ok := err.(*SizeLimitError)
+	if !ok {
+		t.Fatalf("SizeLimitError should be returned but got %T: %v", err, err)
+	}
+	if e.Size != -1 || e.Limit != 100 {
+		t.Errorf("Unexpected error: %+v", e)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be updated: %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package selfupdate
+
+import (
+	"os"
+)
+
+// tryLockFile tries to acquire a lock of the file by creating a marker file exclusively since advisory
+// locking is not available on this platform. A marker file left by a crashed process is never removed
+// because it cannot be distinguished from a lock held by a living process safely. The lock then times out
+// and the cache is not used.
+func tryLockFile(f *os.File) (bool, error) {
+	m, err := os.OpenFile(f.Name()+".excl", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err == nil {
+		m.Close()
+		return true, nil
+	}
+	if os.IsExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func unlockFile(f *os.File) error {
+	return os.Remove(f.Name() + ".excl")
+}
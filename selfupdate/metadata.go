@@ -0,0 +1,80 @@
+package selfupdate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+var reMetadataBlock = regexp.MustCompile(`(?s)<!--\s*selfupdate:?\s(.*?)-->`)
+
+// releaseMetadata is metadata of a release embedded in its release notes as an HTML comment starting
+// with 'selfupdate'. Each line in the comment is a 'key: value' pair. Since it is an HTML comment, it
+// is not shown on the release page.
+//
+//	<!-- selfupdate
+//	yanked: true
+//	-->
+type releaseMetadata map[string]string
+
+func parseReleaseMetadata(body string) releaseMetadata {
+	meta := releaseMetadata{}
+	for _, m := range reMetadataBlock.FindAllStringSubmatch(body, -1) {
+		for _, line := range strings.Split(m[1], "\n") {
+			kv := strings.SplitN(line, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			if key == "" {
+				continue
+			}
+			meta[key] = strings.TrimSpace(kv[1])
+		}
+	}
+	return meta
+}
+
+func (meta releaseMetadata) bool(key string) bool {
+	b, err := strconv.ParseBool(meta[key])
+	return err == nil && b
+}
+
+// minSupportedVersion calculates the minimum supported version as of the version from metadata of the
+// releases up to the version. Critical releases and 'min_supported_version' declarations are considered.
+// It returns nil when no minimum version is declared.
+func minSupportedVersion(rels []*github.RepositoryRelease, ver semver.Version) *semver.Version {
+	var min *semver.Version
+	raise := func(v semver.Version) {
+		if min == nil || v.GT(*min) {
+			min = &v
+		}
+	}
+
+	for _, rel := range rels {
+		if rel.GetDraft() || rel.GetPrerelease() {
+			continue
+		}
+		v, ok := parseTagVersion(rel.GetTagName())
+		if !ok || v.GT(ver) {
+			continue
+		}
+		meta := parseReleaseMetadata(rel.GetBody())
+		if meta.bool("critical") {
+			raise(v)
+		}
+		if s, ok := meta["min_supported_version"]; ok {
+			m, err := semver.ParseTolerant(s)
+			if err != nil {
+				log.Printf("Ignore invalid min_supported_version %q in release %s: %s\n", s, rel.GetTagName(), err)
+				continue
+			}
+			raise(m)
+		}
+	}
+
+	return min
+}
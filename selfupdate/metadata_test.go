@@ -0,0 +1,131 @@
+package selfupdate
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+func TestParseReleaseMetadata(t *testing.T) {
+	body := "## Changes\r\n\r\n- Fix bug\r\n\r\n<!-- selfupdate\r\nYanked: true\r\nnote: broken: do not use\r\ninvalid line\r\n-->\r\n<!-- other comment\r\nfoo: bar\r\n-->\r\n<!-- selfupdate: critical: false -->"
+	meta := parseReleaseMetadata(body)
+
+	for key, want := range map[string]string{
+		"yanked":   "true",
+		"note":     "broken: do not use",
+		"critical": "false",
+	} {
+		if got := meta[key]; got != want {
+			t.Errorf("Wanted %q for key %q but got %q", want, key, got)
+		}
+	}
+	if _, ok := meta["foo"]; ok {
+		t.Error("Comment not starting with 'selfupdate' should be ignored")
+	}
+	if len(meta) != 3 {
+		t.Errorf("Unexpected metadata: %v", meta)
+	}
+
+	if !meta.bool("yanked") {
+		t.Error("'yanked' should be true")
+	}
+	if meta.bool("critical") || meta.bool("note") || meta.bool("unknown") {
+		t.Error("Only 'yanked' should be true")
+	}
+}
+
+func TestParseReleaseMetadataEmpty(t *testing.T) {
+	for _, body := range []string{"", "no metadata", "<!-- selfupdate -->", "<!--selfupdater\nyanked: true\n-->"} {
+		if meta := parseReleaseMetadata(body); len(meta) != 0 {
+			t.Errorf("No metadata should be parsed from %q but got %v", body, meta)
+		}
+	}
+}
+
+func TestMinSupportedVersion(t *testing.T) {
+	rels := []*github.RepositoryRelease{}
+	for i, r := range []testRelease{
+		{tag: "v1.0.0"},
+		{tag: "v1.1.0", body: "<!-- selfupdate\ncritical: true\n-->"},
+		{tag: "v1.2.0", body: "<!-- selfupdate\nmin_supported_version: 1.0.5\n-->"},
+		{tag: "v1.3.0", body: "<!-- selfupdate\nmin_supported_version: v1.2.0\n-->"},
+		{tag: "v1.4.0", body: "<!-- selfupdate\nmin_supported_version: foo\n-->"},
+		{tag: "v1.5.0", body: "<!-- selfupdate\ncritical: true\n-->", prerelease: true},
+		{tag: "v2.0.0", body: "<!-- selfupdate\ncritical: true\n-->", draft: true},
+	} {
+		rels = append(rels, r.toGitHub(int64(i)))
+	}
+
+	for _, tc := range []struct {
+		version string
+		want    string
+	}{
+		{"1.0.0", ""},
+		{"1.1.0", "1.1.0"},
+		{"1.2.0", "1.1.0"},
+		{"1.3.0", "1.2.0"},
+		{"1.5.0", "1.2.0"},
+		{"2.0.0", "1.2.0"},
+	} {
+		min := minSupportedVersion(rels, semver.MustParse(tc.version))
+		if tc.want == "" {
+			if min != nil {
+				t.Errorf("Wanted no minimum supported version for %s but got %s", tc.version, min)
+			}
+			continue
+		}
+		if min == nil {
+			t.Errorf("Wanted minimum supported version %s for %s but got nil", tc.want, tc.version)
+			continue
+		}
+		if min.String() != tc.want {
+			t.Errorf("Wanted minimum supported version %s for %s but got %s", tc.want, tc.version, min)
+		}
+	}
+}
+
+func TestDetectCriticalRelease(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.0.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", body: "Security fix\n<!-- selfupdate\ncritical: true\n-->", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", assets: []string{platformAsset("foo")}},
+	})
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	rel, ok, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Release was not found")
+	}
+	if rel.Critical {
+		t.Error("Latest release itself is not critical")
+	}
+	for _, tc := range []struct {
+		current string
+		want    bool
+	}{
+		{"1.0.0", true},
+		{"1.0.9-beta", true},
+		{"1.1.0", false},
+		{"1.2.0", false},
+	} {
+		if got := rel.IsCritical(semver.MustParse(tc.current)); got != tc.want {
+			t.Errorf("Wanted IsCritical(%s) to be %v but got %v", tc.current, tc.want, got)
+		}
+	}
+
+	rels, err := up.ListReleases("owner/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rels[1].Critical || rels[0].Critical || rels[2].Critical {
+		t.Error("Only v1.1.0 should be critical")
+	}
+	if rels[2].MinSupportedVersion != nil {
+		t.Error("v1.0.0 should not have minimum supported version:", rels[2].MinSupportedVersion)
+	}
+}
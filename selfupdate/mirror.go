@@ -0,0 +1,68 @@
+package selfupdate
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultMirrorTemplate is the template of asset URLs on mirrors used when Config.MirrorTemplate is empty.
+const defaultMirrorTemplate = "{mirror}/{owner}/{repo}/{tag}/{asset}"
+
+// mirrorURL builds the URL of the asset of the release on the mirror from the template.
+func (up *Updater) mirrorURL(mirror string, rel *Release) string {
+	tmpl := up.mirrorTemplate
+	if tmpl == "" {
+		tmpl = defaultMirrorTemplate
+	}
+	return strings.NewReplacer(
+		"{mirror}", strings.TrimSuffix(mirror, "/"),
+		"{owner}", rel.RepoOwner,
+		"{repo}", rel.RepoName,
+		"{tag}", rel.TagName,
+		"{version}", rel.Version.String(),
+		"{asset}", rel.AssetName,
+	).Replace(tmpl)
+}
+
+// downloadFromMirrors tries downloading the asset of the release from the mirrors in order. An asset downloaded
+// from a mirror is always validated against the validation asset downloaded from GitHub so that a mirror cannot
+// serve tampered bytes. When no mirror served a valid asset, it returns nil so that the caller falls back to
+// downloading from GitHub. The caller must call the returned function to close and remove the file.
+func (up *Updater) downloadFromMirrors(rel *Release, validationData []byte) (*os.File, func()) {
+	if len(up.mirrors) == 0 {
+		return nil, nil
+	}
+
+	for _, mirror := range up.mirrors {
+		u := up.mirrorURL(mirror, rel)
+		log.Println("Downloading asset from mirror", u)
+
+		src, size, err := up.downloadDirectlyFromURL(u)
+		if err != nil {
+			log.Println("Could not download asset from mirror:", err)
+			continue
+		}
+		if rel.AssetByteSize > 0 {
+			size = int64(rel.AssetByteSize)
+		}
+
+		h := up.newHash()
+		f, cleanup, err := up.downloadToFile(src, size, h)
+		src.Close()
+		if err != nil {
+			log.Println("Could not download asset from mirror", u, ":", err)
+			continue
+		}
+
+		if err := up.validateAsset(f, h, validationData); err != nil {
+			log.Println("Asset downloaded from mirror", u, "was rejected:", err)
+			cleanup()
+			continue
+		}
+
+		return f, cleanup
+	}
+
+	log.Println("No mirror served a valid asset. Falling back to downloading from GitHub")
+	return nil, nil
+}
@@ -0,0 +1,114 @@
+package selfupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestMirrorURL(t *testing.T) {
+	rel := &Release{
+		Version:   semver.MustParse("1.2.3"),
+		TagName:   "v1.2.3",
+		AssetName: "foo_linux_amd64.zip",
+		RepoOwner: "owner",
+		RepoName:  "repo",
+	}
+	for _, tc := range []struct {
+		mirror string
+		tmpl   string
+		want   string
+	}{
+		{"https://mirror.example.com", "", "https://mirror.example.com/owner/repo/v1.2.3/foo_linux_amd64.zip"},
+		{"https://mirror.example.com/", "", "https://mirror.example.com/owner/repo/v1.2.3/foo_linux_amd64.zip"},
+		{"https://artifactory.example.com/github", "{mirror}/{repo}/{version}/{asset}", "https://artifactory.example.com/github/repo/1.2.3/foo_linux_amd64.zip"},
+	} {
+		up := &Updater{mirrorTemplate: tc.tmpl}
+		if got := up.mirrorURL(tc.mirror, rel); got != tc.want {
+			t.Errorf("Wanted %q but got %q", tc.want, got)
+		}
+	}
+}
+
+func TestMirrorsRequireValidator(t *testing.T) {
+	_, err := NewUpdater(Config{APIToken: "hogehoge", Mirrors: []string{"https://mirror.example.com"}})
+	if err == nil {
+		t.Fatal("Error should occur when mirrors are used without validator")
+	}
+	if !strings.Contains(err.Error(), "Validator is required") {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestUpdateCommandFromMirror(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	asset := platformAsset("foo")
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	primary := 0
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		primary++
+		w.Write(rel.files[asset])
+	})
+
+	requested := []string{}
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/tampered/"):
+			w.Write(zipRelease(t, "v6.6.6", "").files[asset])
+		case strings.HasPrefix(r.URL.Path, "/good/"):
+			w.Write(rel.files[asset])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mirror.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{
+		Validator: &SHA2Validator{},
+		Mirrors:   []string{mirror.URL + "/missing", mirror.URL + "/tampered", mirror.URL + "/good/"},
+	})
+	if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable should be updated with valid asset from mirror: %q", got)
+	}
+	want := []string{
+		"/missing/owner/repo/v1.1.0/" + asset,
+		"/tampered/owner/repo/v1.1.0/" + asset,
+		"/good/owner/repo/v1.1.0/" + asset,
+	}
+	if strings.Join(requested, ",") != strings.Join(want, ",") {
+		t.Errorf("Wanted mirrors %q to be tried in order but got %q", want, requested)
+	}
+	if primary != 0 {
+		t.Error("Asset should not be downloaded from GitHub when mirror served valid asset")
+	}
+
+	// Fall back to GitHub when no mirror served a valid asset
+	requested = nil
+	exe2, cleanup2 := newTestExecutable(t, "v1.0.0")
+	defer cleanup2()
+	up = newTestUpdater(t, srv, Config{
+		Validator: &SHA2Validator{},
+		Mirrors:   []string{mirror.URL + "/missing", mirror.URL + "/tampered"},
+	})
+	if _, err := up.UpdateCommand(exe2, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe2); got != "v1.1.0" {
+		t.Errorf("Executable should be updated with asset from GitHub: %q", got)
+	}
+	if len(requested) != 2 || primary != 1 {
+		t.Errorf("All mirrors should be tried before GitHub: %q, %d", requested, primary)
+	}
+}
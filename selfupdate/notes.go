@@ -0,0 +1,354 @@
+package selfupdate
+
+import (
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiStrike    = "\x1b[9m"
+	ansiCyan      = "\x1b[36m"
+)
+
+var (
+	reHTMLComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	reHeading       = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	reHorizontal    = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	reListItem      = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	reTaskBox       = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	reBlockquote    = regexp.MustCompile(`^ {0,3}>\s?(.*)$`)
+	reCodeFence     = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	reTableDelim    = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	reImage         = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	reLink          = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	reAutoLink      = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	reBold          = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	reItalic        = regexp.MustCompile(`(^|[^\w*])\*([^*\s][^*]*)\*|(^|[^\w])_([^_\s][^_]*)_([^\w]|$)`)
+	reStrike        = regexp.MustCompile(`~~([^~]+)~~`)
+	reHTMLTag       = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^>]*)?/?>`)
+	reEscaped       = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|>~])`)
+	reANSI          = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	reCodeSpanSplit = regexp.MustCompile("`+[^`]+`+")
+)
+
+// NotesRenderer renders release notes written in GitHub-flavored markdown as text which is readable on
+// terminal. Markdown syntax such as headings, links and HTML comments is converted into plain text or
+// text styled with ANSI escape sequences.
+type NotesRenderer struct {
+	// Width is the maximum width of rendered lines. When it is zero or negative, lines are not wrapped.
+	Width int
+	// Color enables styling rendered text with ANSI escape sequences.
+	Color bool
+}
+
+// NewNotesRenderer creates a renderer suitable for the given output. When the output is a terminal,
+// styling with ANSI escape sequences is enabled unless $NO_COLOR is set or $TERM is 'dumb'. Width is
+// the width of the terminal. When it is not available, it is taken from $COLUMNS and defaults to 80.
+func NewNotesRenderer(out io.Writer) *NotesRenderer {
+	width := terminalWidth(out)
+	if width <= 0 {
+		width = 80
+		if c, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && c > 0 {
+			width = c
+		}
+	}
+	color := isTerminal(out) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	return &NotesRenderer{Width: width, Color: color}
+}
+
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the number of columns of the terminal connected to the output. It returns zero when
+// the output is not a terminal or its size is not available.
+func terminalWidth(out io.Writer) int {
+	if !isTerminal(out) {
+		return 0
+	}
+	fd := int(out.(*os.File).Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	w, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// WriteReleaseNotes renders the release notes with a renderer created by NewNotesRenderer for the output
+// and writes the result to the output.
+func WriteReleaseNotes(out io.Writer, notes string) error {
+	_, err := io.WriteString(out, NewNotesRenderer(out).Render(notes))
+	return err
+}
+
+func (r *NotesRenderer) style(s, code string) string {
+	if !r.Color || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (r *NotesRenderer) renderCodeSpan(code string) string {
+	code = strings.TrimSpace(strings.Trim(code, "`"))
+	if r.Color {
+		return r.style(code, ansiCyan)
+	}
+	return "`" + code + "`"
+}
+
+func (r *NotesRenderer) renderInlineText(s string) string {
+	s = reImage.ReplaceAllString(s, "$1 ($2)")
+	s = reLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reLink.FindStringSubmatch(m)
+		text, url := sub[1], sub[2]
+		if text == url {
+			return r.style(url, ansiUnderline)
+		}
+		return r.style(text, ansiUnderline) + " (" + r.style(url, ansiDim) + ")"
+	})
+	s = reAutoLink.ReplaceAllStringFunc(s, func(m string) string {
+		return r.style(reAutoLink.FindStringSubmatch(m)[1], ansiUnderline)
+	})
+	s = reHTMLTag.ReplaceAllString(s, "")
+	s = reBold.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reBold.FindStringSubmatch(m)
+		return r.style(sub[1]+sub[2], ansiBold)
+	})
+	s = reStrike.ReplaceAllStringFunc(s, func(m string) string {
+		return r.style(reStrike.FindStringSubmatch(m)[1], ansiStrike)
+	})
+	s = reItalic.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reItalic.FindStringSubmatch(m)
+		if sub[2] != "" {
+			return sub[1] + r.style(sub[2], ansiItalic)
+		}
+		return sub[3] + r.style(sub[4], ansiItalic) + sub[5]
+	})
+	s = reEscaped.ReplaceAllString(s, "$1")
+	return html.UnescapeString(s)
+}
+
+// renderInline converts inline markdown syntax. Code spans are kept as-is.
+func (r *NotesRenderer) renderInline(s string) string {
+	var b strings.Builder
+	prev := 0
+	for _, idx := range reCodeSpanSplit.FindAllStringIndex(s, -1) {
+		b.WriteString(r.renderInlineText(s[prev:idx[0]]))
+		b.WriteString(r.renderCodeSpan(s[idx[0]:idx[1]]))
+		prev = idx[1]
+	}
+	b.WriteString(r.renderInlineText(s[prev:]))
+	return b.String()
+}
+
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(reANSI.ReplaceAllString(s, ""))
+}
+
+// wrap splits the text into lines at most r.Width wide. The first line is prefixed with 'first' and
+// the rest lines are prefixed with 'rest'. A word longer than the width is not split.
+func (r *NotesRenderer) wrap(text, first, rest string) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{strings.TrimRight(first, " ")}
+	}
+
+	lines := []string{}
+	line := first + words[0]
+	width := visibleWidth(line)
+	for _, w := range words[1:] {
+		ww := visibleWidth(w)
+		if r.Width > 0 && width+1+ww > r.Width {
+			lines = append(lines, line)
+			line = rest + w
+			width = visibleWidth(line)
+			continue
+		}
+		line += " " + w
+		width += 1 + ww
+	}
+	return append(lines, line)
+}
+
+func (r *NotesRenderer) rule() string {
+	w := r.Width
+	if w <= 0 || w > 80 {
+		w = 80
+	}
+	return r.style(strings.Repeat("-", w), ansiDim)
+}
+
+// notesBlock is a block of text which is being accumulated until it is flushed.
+type notesBlock struct {
+	text  []string
+	first string
+	rest  string
+}
+
+// Render converts the markdown text into text readable on terminal.
+func (r *NotesRenderer) Render(markdown string) string {
+	markdown = strings.Replace(markdown, "\r\n", "\n", -1)
+	markdown = reHTMLComment.ReplaceAllString(markdown, "")
+
+	out := []string{}
+	emit := func(lines ...string) {
+		for _, l := range lines {
+			if l == "" && (len(out) == 0 || out[len(out)-1] == "") {
+				continue // Collapse consecutive blank lines
+			}
+			out = append(out, l)
+		}
+	}
+
+	var block *notesBlock
+	flush := func() {
+		if block == nil {
+			return
+		}
+		emit(r.wrap(r.renderInline(strings.Join(block.text, " ")), block.first, block.rest)...)
+		block = nil
+	}
+
+	bullet := "-"
+	quote := "> "
+	if r.Color {
+		bullet = "•"
+		quote = r.style("│", ansiDim) + " "
+	}
+
+	fence := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				emit("")
+				continue
+			}
+			// Code blocks are not wrapped and blank lines in them are preserved
+			out = append(out, strings.TrimRight("    "+r.style(line, ansiCyan), " "))
+			continue
+		}
+
+		if m := reCodeFence.FindStringSubmatch(line); m != nil {
+			flush()
+			emit("")
+			fence = m[1]
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			flush()
+			emit("")
+			continue
+		}
+
+		if reHorizontal.MatchString(line) {
+			flush()
+			emit("", r.rule(), "")
+			continue
+		}
+
+		if m := reHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			text := r.renderInline(m[2])
+			emit("")
+			switch len(m[1]) {
+			case 1:
+				if r.Color {
+					emit(r.style(text, ansiBold+ansiUnderline))
+				} else {
+					emit(text, strings.Repeat("=", visibleWidth(text)))
+				}
+			case 2:
+				if r.Color {
+					emit(r.style(text, ansiBold))
+				} else {
+					emit(text, strings.Repeat("-", visibleWidth(text)))
+				}
+			default:
+				emit(r.style(text, ansiBold))
+			}
+			emit("")
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			flush()
+			if !reTableDelim.MatchString(trimmed) {
+				emit(r.renderInline(trimmed))
+			}
+			continue
+		}
+
+		if m := reListItem.FindStringSubmatch(line); m != nil {
+			flush()
+			indent := strings.Repeat("  ", len(strings.Replace(m[1], "\t", "    ", -1))/2)
+			marker := bullet
+			if c := m[2][0]; c >= '0' && c <= '9' {
+				marker = m[2]
+			}
+			text := m[3]
+			if t := reTaskBox.FindStringSubmatch(text); t != nil {
+				box := "[ ]"
+				if t[1] != " " {
+					box = "[x]"
+				}
+				text = box + " " + text[len(t[0]):]
+			}
+			first := indent + marker + " "
+			block = &notesBlock{[]string{text}, first, indent + strings.Repeat(" ", utf8.RuneCountInString(marker)+1)}
+			continue
+		}
+
+		if m := reBlockquote.FindStringSubmatch(line); m != nil {
+			if block == nil || block.first != quote {
+				flush()
+				block = &notesBlock{first: quote, rest: quote}
+			}
+			if strings.TrimSpace(m[1]) == "" {
+				flush()
+				continue
+			}
+			block.text = append(block.text, m[1])
+			continue
+		}
+
+		if block == nil {
+			block = &notesBlock{}
+		}
+		block.text = append(block.text, trimmed)
+	}
+	flush()
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
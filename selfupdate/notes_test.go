@@ -0,0 +1,121 @@
+package selfupdate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderReleaseNotesPlain(t *testing.T) {
+	notes := "<!-- Release notes template -->\r\n" +
+		"## What's Changed\r\n" +
+		"\r\n" +
+		"* Fix **crash** on `--help` by @foo in [#12](https://github.com/owner/repo/pull/12)\r\n" +
+		"* Support _new_ format\r\n" +
+		"  which is long\r\n" +
+		"  - [x] nested item\r\n" +
+		"\r\n" +
+		"> **Warning**\r\n" +
+		"> Breaking change\r\n" +
+		"\r\n" +
+		"```sh\r\n" +
+		"$ foo --bar\r\n" +
+		"\r\n" +
+		"$ foo --baz\r\n" +
+		"```\r\n" +
+		"---\r\n" +
+		"1. First &amp; <b>bold</b>\r\n" +
+		"\r\n" +
+		"**Full Changelog**: <https://github.com/owner/repo/compare/v1.0.0...v1.1.0>\r\n"
+
+	r := &NotesRenderer{Width: 40}
+	want := `What's Changed
+--------------
+
+- Fix crash on ` + "`--help`" + ` by @foo in #12
+  (https://github.com/owner/repo/pull/12)
+- Support new format which is long
+  - [x] nested item
+
+> Warning Breaking change
+
+    $ foo --bar
+
+    $ foo --baz
+
+----------------------------------------
+
+1. First & bold
+
+Full Changelog:
+https://github.com/owner/repo/compare/v1.0.0...v1.1.0
+`
+	if got := r.Render(notes); got != want {
+		t.Errorf("Rendered text is unexpected.\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRenderReleaseNotesColor(t *testing.T) {
+	r := &NotesRenderer{Color: true}
+	got := r.Render("# Title\n\nSee [docs](https://example.com) and `code`")
+	for _, want := range []string{
+		ansiBold + ansiUnderline + "Title" + ansiReset,
+		ansiUnderline + "docs" + ansiReset + " (" + ansiDim + "https://example.com" + ansiReset + ")",
+		ansiCyan + "code" + ansiReset,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Rendered text %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "#") || strings.Contains(got, "`") {
+		t.Errorf("Markdown syntax remains in rendered text: %q", got)
+	}
+}
+
+func TestRenderReleaseNotesNoWrap(t *testing.T) {
+	r := &NotesRenderer{}
+	text := strings.Repeat("word ", 50)
+	got := r.Render(text)
+	if strings.Count(got, "\n") != 1 {
+		t.Errorf("Text should not be wrapped when width is zero: %q", got)
+	}
+	if r.Render("<!-- only comment -->\n\n") != "" {
+		t.Error("Empty release notes should be rendered as empty text")
+	}
+}
+
+func TestWriteReleaseNotesToNonTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReleaseNotes(&buf, "## Fixed\n\n- **bug**"); err != nil {
+		t.Fatal(err)
+	}
+	want := "Fixed\n-----\n\n- bug\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Wanted %q but got %q", want, got)
+	}
+}
+
+func TestNewNotesRendererWidth(t *testing.T) {
+	f, err := ioutil.TempFile("", "selfupdate-notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	restore := setenvForTest(t, "COLUMNS", "")
+	defer restore()
+	if w := NewNotesRenderer(f).Width; w != 80 {
+		t.Error("Width should default to 80 for non-terminal output:", w)
+	}
+
+	os.Setenv("COLUMNS", "120")
+	if w := NewNotesRenderer(f).Width; w != 120 {
+		t.Error("Width should be taken from $COLUMNS when terminal size is not available:", w)
+	}
+	if w := terminalWidth(&bytes.Buffer{}); w != 0 {
+		t.Error("Terminal width should not be available for non-file output:", w)
+	}
+}
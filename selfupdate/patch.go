@@ -0,0 +1,168 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+	"github.com/inconshreveable/go-update"
+)
+
+// Patch represents a binary delta asset to update an executable of an older version to the release.
+// The patch is a bsdiff patch of the executable (not of the archived asset) named
+// '<asset>.from-<version>.patch'. It must be accompanied by '<asset>.from-<version>.patch.sha256' which
+// contains the SHA-256 checksum of the executable after patching.
+type Patch struct {
+	// From is the version of the executable which the patch is applied to
+	From semver.Version
+	// AssetName is the file name of the patch asset
+	AssetName string
+	// AssetByteSize is the size of the patch asset in bytes
+	AssetByteSize int
+	// AssetID is the ID of the patch asset on GitHub
+	AssetID int64
+	// ChecksumAssetID is the ID of the asset of the checksum of the patched executable on GitHub
+	ChecksumAssetID int64
+	// ValidationAssetID is the ID of the asset to validate the checksum asset with the validator. It is -1
+	// when no validator is configured
+	ValidationAssetID int64
+}
+
+// findPatches finds binary delta assets for the asset in the release. A patch whose checksum asset or
+// validation asset is missing is ignored.
+func (up *Updater) findPatches(rel *github.RepositoryRelease, asset *github.ReleaseAsset) []*Patch {
+	prefix := asset.GetName() + ".from-"
+	patches := []*Patch{}
+	for _, a := range rel.Assets {
+		name := a.GetName()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".patch") {
+			continue
+		}
+		from, err := semver.ParseTolerant(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".patch"))
+		if err != nil {
+			log.Println("Skip patch asset", name, "since its version is invalid:", err)
+			continue
+		}
+
+		checksum, ok := findValidationAsset(rel, name+".sha256")
+		if !ok {
+			log.Println("Skip patch asset", name, "since its checksum asset was not found")
+			continue
+		}
+		p := &Patch{
+			From:              from,
+			AssetName:         name,
+			AssetByteSize:     a.GetSize(),
+			AssetID:           a.GetID(),
+			ChecksumAssetID:   checksum.GetID(),
+			ValidationAssetID: -1,
+		}
+
+		if up.validator != nil {
+			validation, validationName, ok := up.findValidationAssetFor(rel, checksum.GetName())
+			if !ok {
+				log.Println("Skip patch asset", name, "since validation file", validationName, "was not found")
+				continue
+			}
+			p.ValidationAssetID = validation.GetID()
+		}
+
+		patches = append(patches, p)
+	}
+	return patches
+}
+
+// findPatch returns the patch to update the current version. It returns nil when no patch from the
+// current version exists.
+func findPatch(patches []*Patch, current semver.Version) *Patch {
+	for _, p := range patches {
+		if p.From.Equals(current) {
+			return p
+		}
+	}
+	return nil
+}
+
+// downloadPatchChecksum downloads the checksum of the patched executable and validates it with the validator.
+func (up *Updater) downloadPatchChecksum(rel *Release, p *Patch) ([]byte, error) {
+	src, _, err := up.downloadReleaseAsset(rel, p.ChecksumAssetID, "a checksum asset of patch")
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading checksum asset of patch: %v", err)
+	}
+
+	if up.validator != nil {
+		src, _, err := up.downloadReleaseAsset(rel, p.ValidationAssetID, "an validation asset of patch checksum")
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		validationData, err := ioutil.ReadAll(src)
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading validation asset of patch checksum: %v", err)
+		}
+		validationData, err = up.selectValidationData(p.AssetName+".sha256", validationData)
+		if err != nil {
+			return nil, err
+		}
+		if err := up.validator.Validate(data, validationData); err != nil {
+			return nil, fmt.Errorf("Failed validating checksum asset of patch: %v", err)
+		}
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("Checksum asset of patch %s is empty", p.AssetName)
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("Invalid SHA-256 checksum in checksum asset of patch %s: %q", p.AssetName, fields[0])
+	}
+	return sum, nil
+}
+
+// updateWithPatch updates the command by applying the binary delta asset to the current executable. The
+// patched executable is verified with its checksum before replacing the command. When an error is returned,
+// the command is not modified unless rolling back the replaced executable failed.
+func (up *Updater) updateWithPatch(rel *Release, p *Patch, cmdPath string) error {
+	sum, err := up.downloadPatchChecksum(rel, p)
+	if err != nil {
+		return err
+	}
+
+	src, _, err := up.downloadReleaseAsset(rel, p.AssetID, "a patch asset")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	size := int64(-1)
+	if p.AssetByteSize > 0 {
+		size = int64(p.AssetByteSize)
+	}
+	f, cleanup, err := up.downloadToFile(src, size, nil)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	log.Println("Will update", cmdPath, "from", p.From, "to", rel.Version, "with patch", p.AssetName)
+	if err := update.Apply(f, update.Options{
+		TargetPath: cmdPath,
+		Patcher:    update.NewBSDiffPatcher(),
+		Checksum:   sum,
+	}); err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return fmt.Errorf("Failed to rollback from bad update: %v", rerr)
+		}
+		return fmt.Errorf("Failed to apply patch %s: %v", p.AssetName, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+// testPatch is a bsdiff patch from "v1.0.0" to "v1.1.0" which are the contents of test executables.
+var testPatch = []byte("BSDIFF40,\x00\x00\x00\x00\x00\x00\x00%\x00\x00\x00\x00\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00BZh91AY&SY)<\x8a\xa4\x00\x00\x05@@Q\b@\x00 \x00!\x9a\x01\x9a\x02D\xe9\x8d\xc5ܑN\x14$\nO\"\xa9\x00BZh91AY&SY\xc4]\xef\n\x00\x00\x01@\x00`\x00 \x00!\x00\x82\x93\x17rE8P\x90\xc4]\xef\nBZh9\x17rE8P\x90\x00\x00\x00\x00")
+
+// patchRelease returns a test release which has a zip asset of 'tag', a patch from v1.0.0 and its checksum
+// asset. 'executable' is the content whose checksum is put in the checksum asset.
+func patchRelease(t *testing.T, tag, executable string) testRelease {
+	rel := zipRelease(t, tag, "")
+	patch := platformAsset("foo") + ".from-1.0.0.patch"
+	checksum := []byte(fmt.Sprintf("%x  foo\n", sha256.Sum256([]byte(executable))))
+	rel.assets = append(rel.assets, patch, patch+".sha256", patch+".sha256.sha256")
+	rel.files[patch] = testPatch
+	rel.files[patch+".sha256"] = checksum
+	rel.files[patch+".sha256.sha256"] = []byte(fmt.Sprintf("%x", sha256.Sum256(checksum)))
+	return rel
+}
+
+func TestDetectPatches(t *testing.T) {
+	rel := patchRelease(t, "v1.1.0", "v1.1.0")
+	broken := platformAsset("foo") + ".from-foo.patch"
+	rel.assets = append(rel.assets, broken, broken+".sha256")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+	r, ok, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Release was not detected")
+	}
+	if len(r.Patches) != 1 {
+		t.Fatalf("Only valid patch should be detected: %+v", r.Patches)
+	}
+	p := r.Patches[0]
+	if !p.From.Equals(semver.MustParse("1.0.0")) {
+		t.Error("Unexpected version of patch:", p.From)
+	}
+	if p.AssetID != 202 || p.ChecksumAssetID != 203 || p.ValidationAssetID != 204 {
+		t.Errorf("Unexpected asset IDs of patch: %+v", p)
+	}
+	if r.Patch != nil {
+		t.Error("Patch should not be selected without the current version")
+	}
+
+	c, err := up.Check(semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Release.Patch == nil || c.Release.Patch.AssetID != p.AssetID {
+		t.Errorf("Patch from current version should be selected: %+v", c.Release.Patch)
+	}
+
+	c, err = up.Check(semver.MustParse("0.9.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Release.Patch != nil {
+		t.Errorf("Patch should not be selected when no patch from current version exists: %+v", c.Release.Patch)
+	}
+}
+
+func TestUpdateCommandWithPatch(t *testing.T) {
+	for _, tc := range []struct {
+		what       string
+		executable string
+		full       int
+	}{
+		{"patch is applied", "v1.1.0", 0},
+		{"checksum mismatch falls back to full asset", "v6.6.6", 1},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			rel := patchRelease(t, "v1.1.0", tc.executable)
+			asset := platformAsset("foo")
+			srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+			defer srv.Close()
+
+			full := 0
+			mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+				full++
+				w.Write(rel.files[asset])
+			})
+
+			exe, cleanup := newTestExecutable(t, "v1.0.0")
+			defer cleanup()
+
+			up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+			res, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != Updated {
+				t.Fatal("Command was not updated:", res.Status)
+			}
+			if got := readTestExecutable(t, exe); got != "v1.1.0" {
+				t.Errorf("Executable was not updated: %q", got)
+			}
+			if full != tc.full {
+				t.Errorf("Full asset should be downloaded %d times but downloaded %d times", tc.full, full)
+			}
+		})
+	}
+}
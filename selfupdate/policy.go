@@ -0,0 +1,121 @@
+package selfupdate
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+)
+
+// BumpLevel represents how large version bump is allowed by UpdatePolicy.
+type BumpLevel int
+
+const (
+	// BumpMajor allows any update including major version bumps. This is the default.
+	BumpMajor BumpLevel = iota
+	// BumpMinor allows updates within the same major version.
+	BumpMinor
+	// BumpPatch allows updates within the same major and minor versions.
+	BumpPatch
+)
+
+func (l BumpLevel) String() string {
+	switch l {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	case BumpPatch:
+		return "patch"
+	default:
+		return fmt.Sprintf("BumpLevel(%d)", int(l))
+	}
+}
+
+// UpdatePolicy represents a policy to restrict which release the current version can be updated to.
+// It is enforced by UpdateCommand and UpdateSelf. Zero value allows any update except for downgrades.
+// When the current version is no longer supported (see Release.IsCritical), releases at or above the
+// minimum supported version are exempt from MaxBump and PinnedMajor so that the mandatory update is
+// always possible.
+type UpdatePolicy struct {
+	// AllowDowngrade allows updating to a release older than the current version. This happens when the
+	// current version is a development build newer than the latest release. Even if this is false,
+	// downgrade is allowed when the current version was yanked.
+	AllowDowngrade bool
+	// MaxBump is the maximum version bump level which is applied automatically. For example, when it is
+	// BumpPatch, only the latest patch release of the current minor version is applied.
+	MaxBump BumpLevel
+	// PinnedMajor restricts updates to releases of the major version. A major update to the version is
+	// allowed regardless of MaxBump. nil means no restriction.
+	PinnedMajor *uint64
+}
+
+// PolicyDecision represents a decision of UpdatePolicy on updating the current version.
+type PolicyDecision struct {
+	// Allowed is false when the policy rejected a release newer than the one the current version is updated
+	// to, or when it rejected all releases.
+	Allowed bool
+	// Reason describes why the policy rejected the release. It is empty when Allowed is true.
+	Reason string
+	// Rejected is the release rejected by the policy. It is nil when Allowed is true.
+	Rejected *Release
+}
+
+// check returns a reason why updating from the current version to the target version is not allowed.
+// When the update is allowed, it returns an empty string.
+func (p *UpdatePolicy) check(current, target semver.Version, currentYanked bool) string {
+	if target.LT(current) {
+		if !p.AllowDowngrade && !currentYanked {
+			return fmt.Sprintf("downgrade from %s to %s is not allowed", current, target)
+		}
+		return ""
+	}
+
+	if p.PinnedMajor != nil {
+		if target.Major != *p.PinnedMajor {
+			return fmt.Sprintf("major version is pinned to %d but %s was released", *p.PinnedMajor, target)
+		}
+		if current.Major != target.Major {
+			return ""
+		}
+	}
+
+	switch {
+	case p.MaxBump >= BumpMinor && target.Major != current.Major:
+		return fmt.Sprintf("major update from %s to %s is not allowed (max bump level: %s)", current, target, p.MaxBump)
+	case p.MaxBump >= BumpPatch && target.Minor != current.Minor:
+		return fmt.Sprintf("minor update from %s to %s is not allowed (max bump level: %s)", current, target, p.MaxBump)
+	}
+	return ""
+}
+
+// selectCandidate selects the latest candidate which the current version can be updated to under the policy.
+// It also returns the newest candidate rejected by the policy and its reason when the candidate is newer
+// than the selected one. When the current version is older than 'minSupported', candidates at or above it
+// are not checked by the policy since the update is mandatory.
+func (p *UpdatePolicy) selectCandidate(cands []releaseCandidate, current semver.Version, currentYanked bool, minSupported *semver.Version) (*releaseCandidate, *releaseCandidate, string) {
+	critical := minSupported != nil && current.LT(*minSupported)
+	allowed := make([]releaseCandidate, 0, len(cands))
+	var rejected *releaseCandidate
+	reason := ""
+	for i, c := range cands {
+		if critical && c.version.GTE(*minSupported) {
+			log.Println("Update to", c.version, "is exempt from policy because current version", current, "is older than minimum supported version", *minSupported)
+		} else if !c.version.Equals(current) {
+			if r := p.check(current, c.version, currentYanked); r != "" {
+				log.Println("Update to", c.version, "was rejected by policy:", r)
+				if rejected == nil || c.version.GT(rejected.version) {
+					rejected = &cands[i]
+					reason = r
+				}
+				continue
+			}
+		}
+		allowed = append(allowed, c)
+	}
+
+	selected := latestCandidate(allowed)
+	if rejected != nil && selected != nil && rejected.version.LTE(selected.version) {
+		return selected, nil, ""
+	}
+	return selected, rejected, reason
+}
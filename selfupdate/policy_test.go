@@ -0,0 +1,204 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestUpdatePolicyCheck(t *testing.T) {
+	major := func(v uint64) *uint64 { return &v }
+
+	for _, tc := range []struct {
+		what    string
+		policy  UpdatePolicy
+		current string
+		target  string
+		yanked  bool
+		reason  string
+	}{
+		{"upgrade by default", UpdatePolicy{}, "1.0.0", "2.0.0", false, ""},
+		{"downgrade by default", UpdatePolicy{}, "2.0.0", "1.0.0", false, "downgrade from 2.0.0 to 1.0.0 is not allowed"},
+		{"downgrade from prerelease", UpdatePolicy{}, "1.0.0", "1.0.0-beta", false, "downgrade"},
+		{"downgrade allowed", UpdatePolicy{AllowDowngrade: true}, "2.0.0", "1.0.0", false, ""},
+		{"downgrade from yanked", UpdatePolicy{}, "2.0.0", "1.0.0", true, ""},
+		{"patch with max patch", UpdatePolicy{MaxBump: BumpPatch}, "1.2.3", "1.2.4", false, ""},
+		{"minor with max patch", UpdatePolicy{MaxBump: BumpPatch}, "1.2.3", "1.3.0", false, "minor update from 1.2.3 to 1.3.0 is not allowed (max bump level: patch)"},
+		{"major with max patch", UpdatePolicy{MaxBump: BumpPatch}, "1.2.3", "2.0.0", false, "major update"},
+		{"minor with max minor", UpdatePolicy{MaxBump: BumpMinor}, "1.2.3", "1.3.0", false, ""},
+		{"major with max minor", UpdatePolicy{MaxBump: BumpMinor}, "1.2.3", "2.0.0", false, "major update from 1.2.3 to 2.0.0 is not allowed (max bump level: minor)"},
+		{"pinned major", UpdatePolicy{PinnedMajor: major(1)}, "1.2.3", "1.9.0", false, ""},
+		{"other than pinned major", UpdatePolicy{PinnedMajor: major(1)}, "1.2.3", "2.0.0", false, "major version is pinned to 1 but 2.0.0 was released"},
+		{"opt-in to pinned major", UpdatePolicy{PinnedMajor: major(2), MaxBump: BumpPatch}, "1.2.3", "2.1.0", false, ""},
+		{"minor in pinned major", UpdatePolicy{PinnedMajor: major(2), MaxBump: BumpPatch}, "2.0.0", "2.1.0", false, "minor update"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			reason := tc.policy.check(semver.MustParse(tc.current), semver.MustParse(tc.target), tc.yanked)
+			if tc.reason == "" {
+				if reason != "" {
+					t.Fatal("Update should be allowed but rejected:", reason)
+				}
+				return
+			}
+			if !strings.Contains(reason, tc.reason) {
+				t.Fatalf("Wanted reason containing %q but got %q", tc.reason, reason)
+			}
+		})
+	}
+}
+
+// newTestExecutable creates a fake executable file whose content is the given text in a temporary directory.
+func newTestExecutable(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "selfupdate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "foo")
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	if err := ioutil.WriteFile(exe, []byte(content), 0755); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return exe, func() { os.RemoveAll(dir) }
+}
+
+func readTestExecutable(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestUpdateCommandWithPolicy(t *testing.T) {
+	rels := []testRelease{
+		binaryRelease("v1.2.0"),
+		binaryRelease("v1.2.1"),
+		binaryRelease("v1.3.0"),
+		binaryRelease("v2.0.0"),
+	}
+	srv, _ := newTestAPIServer(t, rels)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		what     string
+		policy   UpdatePolicy
+		current  string
+		want     string
+		allowed  bool
+		rejected string
+	}{
+		{"default", UpdatePolicy{}, "1.2.0", "v2.0.0", true, ""},
+		{"up to date", UpdatePolicy{}, "2.0.0", "v2.0.0", true, ""},
+		{"max patch", UpdatePolicy{MaxBump: BumpPatch}, "1.2.0", "v1.2.1", false, "2.0.0"},
+		{"max minor", UpdatePolicy{MaxBump: BumpMinor}, "1.2.0", "v1.3.0", false, "2.0.0"},
+		{"max minor up to date", UpdatePolicy{MaxBump: BumpMinor}, "1.3.0", "v1.3.0", false, "2.0.0"},
+		{"ahead", UpdatePolicy{}, "2.1.0", "v2.1.0", false, "2.0.0"},
+		{"ahead allow downgrade", UpdatePolicy{AllowDowngrade: true}, "2.1.0", "v2.0.0", true, ""},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			exe, cleanup := newTestExecutable(t, "v"+tc.current)
+			defer cleanup()
+
+			up := newTestUpdater(t, srv, Config{Policy: tc.policy})
+			res, err := up.UpdateCommand(exe, semver.MustParse(tc.current), "owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestExecutable(t, exe); got != tc.want {
+				t.Errorf("Wanted executable %q but got %q", tc.want, got)
+			}
+			if v := "v" + res.Version.String(); v != tc.want {
+				t.Errorf("Wanted version %s in result but got %s", tc.want, v)
+			}
+			if res.Decision.Allowed != tc.allowed {
+				t.Errorf("Wanted allowed=%v but got %v: %s", tc.allowed, res.Decision.Allowed, res.Decision.Reason)
+			}
+			if tc.rejected == "" {
+				if res.Decision.Rejected != nil || res.Decision.Reason != "" {
+					t.Errorf("Nothing should be rejected: %+v", res.Decision)
+				}
+				return
+			}
+			if res.Decision.Rejected == nil || res.Decision.Rejected.Version.String() != tc.rejected {
+				t.Errorf("Wanted %s to be rejected but got %+v", tc.rejected, res.Decision.Rejected)
+			}
+			if res.Decision.Reason == "" {
+				t.Error("Reason should be set when a release was rejected")
+			}
+		})
+	}
+}
+
+func TestUpdateCommandMovesOffYankedVersion(t *testing.T) {
+	yanked := binaryRelease("v2.0.0")
+	yanked.body = "<!-- selfupdate\nyanked: true\n-->"
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.9.0"), yanked})
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v2.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{})
+	res, err := up.UpdateCommand(exe, semver.MustParse("2.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version.String() != "1.9.0" || !res.Decision.Allowed {
+		t.Errorf("Yanked version should be replaced with v1.9.0: %+v", res)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.9.0" {
+		t.Errorf("Executable was not updated: %q", got)
+	}
+}
+
+func TestUpdateCommandWithPolicyForCriticalRelease(t *testing.T) {
+	critical := binaryRelease("v2.0.1")
+	critical.body = "<!-- selfupdate\nmin_supported_version: 2.0.1\n-->"
+	rels := []testRelease{
+		binaryRelease("v1.2.0"),
+		binaryRelease("v1.2.1"),
+		binaryRelease("v2.0.0"),
+		critical,
+		binaryRelease("v2.1.0"),
+	}
+	srv, _ := newTestAPIServer(t, rels)
+	defer srv.Close()
+	major := func(v uint64) *uint64 { return &v }
+
+	for _, tc := range []struct {
+		what    string
+		policy  UpdatePolicy
+		current string
+		want    string
+		allowed bool
+	}{
+		{"max patch", UpdatePolicy{MaxBump: BumpPatch}, "1.2.0", "v2.1.0", true},
+		{"pinned major", UpdatePolicy{PinnedMajor: major(1)}, "1.2.1", "v2.1.0", true},
+		{"supported version", UpdatePolicy{MaxBump: BumpPatch}, "2.0.1", "v2.0.1", false},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			exe, cleanup := newTestExecutable(t, "v"+tc.current)
+			defer cleanup()
+
+			up := newTestUpdater(t, srv, Config{Policy: tc.policy})
+			res, err := up.UpdateCommand(exe, semver.MustParse(tc.current), "owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestExecutable(t, exe); got != tc.want {
+				t.Errorf("Wanted executable %q but got %q", tc.want, got)
+			}
+			if res.Decision.Allowed != tc.allowed {
+				t.Errorf("Wanted allowed=%v but got %v: %s", tc.allowed, res.Decision.Allowed, res.Decision.Reason)
+			}
+		})
+	}
+}
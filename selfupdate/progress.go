@@ -0,0 +1,182 @@
+package selfupdate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ProgressPhase represents a phase of updating a command.
+type ProgressPhase int
+
+const (
+	// PhaseDownload is the phase to download the release asset.
+	PhaseDownload ProgressPhase = iota
+	// PhaseValidate is the phase to validate the downloaded asset with the validator.
+	PhaseValidate
+	// PhaseExtract is the phase to extract the executable from the downloaded asset.
+	PhaseExtract
+	// PhaseApply is the phase to replace the command with the extracted executable.
+	PhaseApply
+)
+
+func (p ProgressPhase) String() string {
+	switch p {
+	case PhaseDownload:
+		return "download"
+	case PhaseValidate:
+		return "validate"
+	case PhaseExtract:
+		return "extract"
+	case PhaseApply:
+		return "apply"
+	default:
+		return fmt.Sprintf("ProgressPhase(%d)", int(p))
+	}
+}
+
+// Progress represents progress of updating a command.
+type Progress struct {
+	// Phase is the current phase of the update.
+	Phase ProgressPhase
+	// Done is the number of bytes processed in the phase so far.
+	Done int64
+	// Total is the number of bytes to be processed in the phase. It is negative when the total is unknown.
+	// The total of downloading is the size of the asset or Content-Length of the response.
+	Total int64
+}
+
+// ProgressFunc is a callback to receive progress of updating a command. It is called repeatedly while
+// downloading and extracting, and at the start and the end of validating and applying.
+type ProgressFunc func(Progress)
+
+func (up *Updater) reportProgress(phase ProgressPhase, done, total int64) {
+	if up.progress != nil {
+		up.progress(Progress{Phase: phase, Done: done, Total: total})
+	}
+}
+
+// progressReader is a reader which reports the number of bytes read so far.
+type progressReader struct {
+	r     io.Reader
+	up    *Updater
+	phase ProgressPhase
+	done  int64
+	total int64
+	// onEOF is called once when the underlying reader reaches EOF.
+	onEOF func(done int64)
+}
+
+func (up *Updater) newProgressReader(r io.Reader, phase ProgressPhase, total int64) *progressReader {
+	up.reportProgress(phase, 0, total)
+	return &progressReader{r: r, up: up, phase: phase, total: total}
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if n > 0 {
+		r.done += int64(n)
+		r.up.reportProgress(r.phase, r.done, r.total)
+	}
+	if err == io.EOF && r.onEOF != nil {
+		r.onEOF(r.done)
+		r.onEOF = nil
+	}
+	return n, err
+}
+
+// ProgressBar renders progress of updating a command as a progress bar on terminal. When the output is not
+// a terminal, only a line is written at the end of each phase. Its Update method can be set to
+// Config.Progress.
+//
+//	bar := selfupdate.NewProgressBar(os.Stderr)
+//	up, err := selfupdate.NewUpdater(selfupdate.Config{Progress: bar.Update})
+type ProgressBar struct {
+	// Width is the number of columns of the bar itself.
+	Width int
+	// Interval is the minimum interval of redrawing the bar.
+	Interval time.Duration
+
+	out      io.Writer
+	terminal bool
+	started  bool
+	finished bool
+	last     Progress
+	drawn    time.Time
+}
+
+// NewProgressBar creates a progress bar which writes to the output.
+func NewProgressBar(out io.Writer) *ProgressBar {
+	return &ProgressBar{
+		Width:    30,
+		Interval: 100 * time.Millisecond,
+		out:      out,
+		terminal: isTerminal(out),
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (b *ProgressBar) render(p Progress) string {
+	if p.Total <= 0 {
+		return fmt.Sprintf("%-8s %s", p.Phase, formatBytes(p.Done))
+	}
+	ratio := float64(p.Done) / float64(p.Total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio * float64(b.Width))
+	bar := strings.Repeat("=", filled)
+	if filled < b.Width {
+		bar += ">" + strings.Repeat(" ", b.Width-filled-1)
+	}
+	return fmt.Sprintf("%-8s [%s] %3.0f%% %s / %s", p.Phase, bar, ratio*100, formatBytes(p.Done), formatBytes(p.Total))
+}
+
+// Update renders the progress. It is intended to be set to Config.Progress.
+func (b *ProgressBar) Update(p Progress) {
+	if b.started && p.Phase != b.last.Phase {
+		// Complete the previous phase even if its total was unknown
+		b.finish()
+		b.finished = false
+		b.drawn = time.Time{}
+	}
+	b.started = true
+	if b.finished {
+		return
+	}
+	b.last = p
+
+	complete := p.Total >= 0 && p.Done >= p.Total
+	if complete {
+		b.finish()
+		return
+	}
+	if now := time.Now(); b.terminal && now.Sub(b.drawn) >= b.Interval {
+		fmt.Fprintf(b.out, "\r\x1b[K%s", b.render(p))
+		b.drawn = now
+	}
+}
+
+func (b *ProgressBar) finish() {
+	if b.finished {
+		return
+	}
+	if b.terminal {
+		fmt.Fprint(b.out, "\r\x1b[K")
+	}
+	fmt.Fprintln(b.out, b.render(b.last))
+	b.finished = true
+}
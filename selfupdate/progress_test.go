@@ -0,0 +1,197 @@
+package selfupdate
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestProgressPhaseString(t *testing.T) {
+	for p, want := range map[ProgressPhase]string{
+		PhaseDownload:     "download",
+		PhaseValidate:     "validate",
+		PhaseExtract:      "extract",
+		PhaseApply:        "apply",
+		ProgressPhase(10): "ProgressPhase(10)",
+	} {
+		if got := p.String(); got != want {
+			t.Errorf("Wanted %q but got %q", want, got)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1.0 KiB",
+		1536:                   "1.5 KiB",
+		5 * 1024 * 1024:        "5.0 MiB",
+		3 * 1024 * 1024 * 1024: "3.0 GiB",
+	} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("Wanted %q for %d but got %q", want, n, got)
+		}
+	}
+}
+
+// recordProgress returns a progress callback which records all reported progress.
+func recordProgress() (*[]Progress, ProgressFunc) {
+	ps := []Progress{}
+	return &ps, func(p Progress) {
+		ps = append(ps, p)
+	}
+}
+
+func checkProgress(t *testing.T, ps []Progress, phases []ProgressPhase, downloaded int64) {
+	seen := []ProgressPhase{}
+	for i, p := range ps {
+		if len(seen) == 0 || seen[len(seen)-1] != p.Phase {
+			seen = append(seen, p.Phase)
+			if p.Done != 0 {
+				t.Errorf("Phase %s should start with zero: %+v", p.Phase, p)
+			}
+			continue
+		}
+		if prev := ps[i-1]; p.Done < prev.Done {
+			t.Errorf("Progress should not go backward: %+v -> %+v", prev, p)
+		}
+	}
+	if len(seen) != len(phases) {
+		t.Fatalf("Wanted phases %v but got %v", phases, seen)
+	}
+	for i := range phases {
+		if seen[i] != phases[i] {
+			t.Fatalf("Wanted phases %v but got %v", phases, seen)
+		}
+	}
+
+	var last Progress
+	for _, p := range ps {
+		if p.Phase == PhaseDownload {
+			last = p
+		}
+	}
+	if last.Done != downloaded || last.Total != downloaded {
+		t.Errorf("Download should end with %d bytes: %+v", downloaded, last)
+	}
+	if end := ps[len(ps)-1]; end.Phase != PhaseApply || end.Done != end.Total || end.Total <= 0 {
+		t.Errorf("Progress should end with completion of applying: %+v", end)
+	}
+}
+
+func TestUpdateCommandReportsProgress(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+	size := int64(len(rel.files[platformAsset("foo")]))
+
+	for _, tc := range []struct {
+		validator Validator
+		phases    []ProgressPhase
+	}{
+		{nil, []ProgressPhase{PhaseDownload, PhaseExtract, PhaseApply}},
+		{&SHA2Validator{}, []ProgressPhase{PhaseDownload, PhaseValidate, PhaseExtract, PhaseApply}},
+	} {
+		exe, cleanup := newTestExecutable(t, "v1.0.0")
+		defer cleanup()
+
+		ps, fn := recordProgress()
+		up := newTestUpdater(t, srv, Config{Validator: tc.validator, Progress: fn})
+		if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+			t.Fatal(err)
+		}
+		checkProgress(t, *ps, tc.phases, size)
+	}
+}
+
+func TestUpdateToURLReportsProgress(t *testing.T) {
+	content := strings.Repeat("x", 100*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	ps, fn := recordProgress()
+	up := newTestUpdater(t, srv, Config{Progress: fn})
+	if err := up.UpdateToURL(srv.URL+"/foo", exe); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, *ps, []ProgressPhase{PhaseDownload, PhaseExtract, PhaseApply}, int64(len(content)))
+	if got := readTestExecutable(t, exe); got != content {
+		t.Error("Executable was not updated")
+	}
+}
+
+func TestUpdateToWithProgress(t *testing.T) {
+	content := strings.Repeat("x", 100*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	ps, fn := recordProgress()
+	if err := UpdateToWithProgress(srv.URL+"/foo", exe, fn); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, *ps, []ProgressPhase{PhaseDownload, PhaseExtract, PhaseApply}, int64(len(content)))
+	if got := readTestExecutable(t, exe); got != content {
+		t.Error("Executable was not updated")
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	for _, p := range []Progress{
+		{PhaseDownload, 0, 2048},
+		{PhaseDownload, 1024, 2048},
+		{PhaseDownload, 2048, 2048},
+		{PhaseExtract, 0, -1},
+		{PhaseExtract, 4096, -1},
+		{PhaseApply, 0, 4096},
+		{PhaseApply, 4096, 4096},
+	} {
+		bar.Update(p)
+	}
+	want := "download [==============================] 100% 2.0 KiB / 2.0 KiB\n" +
+		"extract  4.0 KiB\n" +
+		"apply    [==============================] 100% 4.0 KiB / 4.0 KiB\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Wanted %q but got %q", want, got)
+	}
+}
+
+func TestProgressBarOnTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	bar.terminal = true
+	bar.Interval = 0
+	bar.Width = 10
+	for _, p := range []Progress{
+		{PhaseDownload, 0, 100},
+		{PhaseDownload, 50, 100},
+		{PhaseDownload, 100, 100},
+	} {
+		bar.Update(p)
+	}
+	want := "\r\x1b[Kdownload [>         ]   0% 0 B / 100 B" +
+		"\r\x1b[Kdownload [=====>    ]  50% 50 B / 100 B" +
+		"\r\x1b[Kdownload [==========] 100% 100 B / 100 B\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Wanted %q but got %q", want, got)
+	}
+}
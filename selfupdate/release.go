@@ -12,12 +12,16 @@ type Release struct {
 	Version semver.Version
 	// AssetURL is a URL to the uploaded file for the release
 	AssetURL string
+	// AssetName is the file name of the asset
+	AssetName string
 	// AssetSize represents the size of asset in bytes
 	AssetByteSize int
 	// AssetID is the ID of the asset on GitHub
 	AssetID int64
 	// ValidationAssetID is the ID of additional validaton asset on GitHub
 	ValidationAssetID int64
+	// TagName is the name of the Git tag of the release
+	TagName string
 	// URL is a URL to release page for browsing
 	URL string
 	// ReleaseNotes is a release notes of the release
@@ -30,4 +34,27 @@ type Release struct {
 	RepoOwner string
 	// RepoName is the name of the repository of the release
 	RepoName string
+	// Draft is true when the release is a draft which is not published yet. Its asset can be downloaded
+	// only via GitHub API with an API token
+	Draft bool
+	// Critical is true when the release is marked as a critical update such as a security fix by
+	// 'critical: true' in the metadata of its release notes
+	Critical bool
+	// MinSupportedVersion is the minimum version which is still supported as of the release. It is declared
+	// by 'min_supported_version' in the metadata of release notes. Critical releases up to the release also
+	// raise it. nil means that no minimum version is declared
+	MinSupportedVersion *semver.Version
+	// Patches are binary delta assets to update executables of older versions to the release
+	Patches []*Patch
+	// Patch is the binary delta asset from the current version. It is set when the release was selected
+	// with the current version by Check, UpdateCommand or UpdateSelf and a patch from the version exists.
+	// UpdateTo prefers it to the full asset. nil means that the full asset is downloaded
+	Patch *Patch
+}
+
+// IsCritical returns true when the current version must be updated to the release, because the current
+// version is older than the minimum supported version or a critical release was published after it.
+// CLIs can use this to block their usage or to force update.
+func (r *Release) IsCritical(current semver.Version) bool {
+	return r.MinSupportedVersion != nil && current.LT(*r.MinSupportedVersion)
 }
@@ -0,0 +1,210 @@
+package selfupdate
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partialDownloadPath returns a path to persist the partially downloaded asset of the release. It is keyed
+// by the asset ID and its size so that a partial download is never resumed with a different asset.
+func (up *Updater) partialDownloadPath(rel *Release) string {
+	return filepath.Join(up.cacheDir, fmt.Sprintf("asset-%d-%d.part", rel.AssetID, rel.AssetByteSize))
+}
+
+// contentRangeStart parses the start of the range from the value of Content-Range header such as
+// 'bytes 100-199/200'.
+func contentRangeStart(header string) (int64, bool) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, false
+	}
+	r := strings.TrimPrefix(header, "bytes ")
+	i := strings.IndexByte(r, '-')
+	if i <= 0 {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(r[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+// requestAsset sends a request to download the asset of the release via GitHub Releases API. When the offset
+// is positive, only the rest of the asset after the offset is requested with Range header. Since an OAuth
+// HTTP client is not available for the redirect URL returned from the API, the redirect is followed with
+// the default HTTP client.
+func (up *Updater) requestAsset(rel *Release, offset int64) (*http.Response, error) {
+	u := fmt.Sprintf("repos/%s/%s/releases/assets/%d", rel.RepoOwner, rel.RepoName, rel.AssetID)
+	req, err := up.api.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create HTTP request to GitHub Releases API for getting an asset(ID: %d): %s", rel.AssetID, err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := *up.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	res, err := client.Do(req.WithContext(up.apiCtx))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to call GitHub Releases API for getting an asset(ID: %d) for repository '%s/%s': %s", rel.AssetID, rel.RepoOwner, rel.RepoName, err)
+	}
+
+	if res.StatusCode/100 != 3 || res.Header.Get("Location") == "" {
+		return res, nil
+	}
+	res.Body.Close()
+	redirectURL, err := res.Location()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid redirect URL returned from GitHub Releases API for getting an asset(ID: %d): %s", rel.AssetID, err)
+	}
+	loc := redirectURL.String()
+
+	log.Println("Redirect URL was returned while trying to download an asset from GitHub API. Downloading from the URL directly:", loc)
+	req, err = http.NewRequest("GET", loc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create HTTP request to %s: %s", loc, err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err = http.DefaultClient.Do(req.WithContext(up.apiCtx))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download a release file from %s: %s", loc, err)
+	}
+	return res, nil
+}
+
+// downloadResumable downloads the asset of the release into a file in the cache directory. When a partial
+// download of the same asset remains from a previous attempt, the download is resumed from the end of it.
+// When the server ignores the Range header, the asset is downloaded from the beginning. When downloading
+// fails, the partial file is kept for the next attempt. The final size is verified against the size of the
+// asset and the hash is calculated over the whole content including the resumed part.
+// The caller must call the returned function to close and remove the file.
+func (up *Updater) downloadResumable(rel *Release, h hash.Hash) (*os.File, func(), error) {
+	if err := checkSizeLimit("asset", int64(rel.AssetByteSize), up.maxDownloadBytes); err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(up.cacheDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("Failed to create cache directory %s: %s", up.cacheDir, err)
+	}
+	path := up.partialDownloadPath(rel)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open file %s for downloading asset: %s", path, err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(path)
+	}
+
+	size := int64(rel.AssetByteSize)
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Failed to seek partially downloaded file %s: %s", path, err)
+	}
+	if size > 0 && offset > size {
+		log.Println("Partially downloaded file", path, "is larger than the asset. Download it from the beginning")
+		offset = 0
+	}
+
+	if size <= 0 || offset < size {
+		if offset > 0 {
+			log.Println("Resuming download of asset", rel.AssetID, "from", offset, "bytes")
+		}
+		res, err := up.requestAsset(rel, offset)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		defer res.Body.Close()
+
+		switch res.StatusCode {
+		case http.StatusPartialContent:
+			if start, ok := contentRangeStart(res.Header.Get("Content-Range")); !ok || start != offset {
+				cleanup()
+				return nil, nil, fmt.Errorf("Failed to resume downloading asset from %d bytes: Unexpected Content-Range %q", offset, res.Header.Get("Content-Range"))
+			}
+		case http.StatusOK:
+			if offset > 0 {
+				log.Println("Server ignored Range header. Downloading asset from the beginning")
+				offset = 0
+			}
+		case http.StatusRequestedRangeNotSatisfiable:
+			// The partial file may be broken. Retry next time from scratch
+			cleanup()
+			return nil, nil, fmt.Errorf("Failed to resume downloading asset from %d bytes: Range is not satisfiable", offset)
+		default:
+			f.Close()
+			return nil, nil, fmt.Errorf("Failed to download a release file for asset(ID: %d): Not successful status %d", rel.AssetID, res.StatusCode)
+		}
+
+		if err := f.Truncate(offset); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("Failed to truncate partially downloaded file %s: %s", path, err)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("Failed to seek partially downloaded file %s: %s", path, err)
+		}
+
+		total := size
+		if total <= 0 && res.ContentLength >= 0 {
+			total = offset + res.ContentLength
+		}
+		up.reportProgress(PhaseDownload, offset, total)
+		var body io.Reader = res.Body
+		if up.maxDownloadBytes > 0 {
+			body = &sizeLimitReader{r: res.Body, what: "asset", limit: up.maxDownloadBytes, read: offset}
+		}
+		src := &progressReader{r: body, up: up, phase: PhaseDownload, done: offset, total: total}
+		if _, err := io.Copy(f, src); err != nil {
+			if e, ok := err.(*SizeLimitError); ok {
+				cleanup()
+				return nil, nil, e
+			}
+			f.Close()
+			return nil, nil, fmt.Errorf("Failed reading asset body: %v. Download will be resumed next time", err)
+		}
+	}
+
+	downloaded, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Failed to seek downloaded file %s: %s", path, err)
+	}
+	if size > 0 {
+		if err := checkDownloadSize(downloaded, size); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Failed to rewind downloaded asset file %s: %s", path, err)
+	}
+	if h != nil {
+		if _, err := io.Copy(h, f); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("Failed to calculate hash of downloaded asset %s: %s", path, err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("Failed to rewind downloaded asset file %s: %s", path, err)
+		}
+	}
+
+	return f, cleanup, nil
+}
@@ -0,0 +1,169 @@
+package selfupdate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func TestContentRangeStart(t *testing.T) {
+	for header, want := range map[string]int64{
+		"bytes 0-99/100":  0,
+		"bytes 100-199/*": 100,
+		"bytes 42-42/43":  42,
+	} {
+		start, ok := contentRangeStart(header)
+		if !ok || start != want {
+			t.Errorf("Wanted %d for %q but got %d (%v)", want, header, start, ok)
+		}
+	}
+	for _, header := range []string{"", "bytes */100", "items 0-1/2", "bytes foo-1/2"} {
+		if _, ok := contentRangeStart(header); ok {
+			t.Errorf("%q should not be parsed", header)
+		}
+	}
+}
+
+func newTestCacheDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "selfupdate-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestResumeInterruptedDownload(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	content := rel.files[platformAsset("foo")]
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	ranges := []string{}
+	interrupt := true
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if interrupt {
+			// Connection is closed at the middle of the content
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			return
+		}
+		http.ServeContent(w, r, "foo.zip", time.Time{}, bytes.NewReader(content))
+	})
+
+	dir, cleanupDir := newTestCacheDir(t)
+	defer cleanupDir()
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+	up := newTestUpdater(t, srv, Config{CacheDir: dir, Validator: &SHA2Validator{}})
+
+	if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err == nil {
+		t.Fatal("Error should occur when download was interrupted")
+	}
+	part := filepath.Join(dir, "asset-200-"+strconv.Itoa(len(content))+".part")
+	b, err := ioutil.ReadFile(part)
+	if err != nil {
+		t.Fatal("Partial download should be kept:", err)
+	}
+	if !bytes.Equal(b, content[:len(content)/2]) {
+		t.Fatal("Unexpected partial download content:", b)
+	}
+
+	interrupt = false
+	res, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Updated {
+		t.Errorf("Wanted status %q but got %q", Updated, res.Status)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated: %q", got)
+	}
+	if want := []string{"", "bytes=" + strconv.Itoa(len(content)/2) + "-"}; strings.Join(ranges, ",") != strings.Join(want, ",") {
+		t.Errorf("Wanted Range headers %q but got %q", want, ranges)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Error("Partial download should be removed after update:", err)
+	}
+}
+
+func TestResumeDownloadRangeIgnored(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	content := rel.files[platformAsset("foo")]
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	// Redirect target ignores Range header
+	redirected := []*http.Request{}
+	mux.HandleFunc("/download/foo.zip", func(w http.ResponseWriter, r *http.Request) {
+		redirected = append(redirected, r)
+		w.Write(content)
+	})
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/download/foo.zip", http.StatusFound)
+	})
+
+	dir, cleanupDir := newTestCacheDir(t)
+	defer cleanupDir()
+	part := filepath.Join(dir, "asset-200-"+strconv.Itoa(len(content))+".part")
+	if err := ioutil.WriteFile(part, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{CacheDir: dir, Validator: &SHA2Validator{}})
+	if _, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated: %q", got)
+	}
+	if len(redirected) != 1 {
+		t.Fatal("Download should be redirected once:", len(redirected))
+	}
+	if r := redirected[0].Header.Get("Range"); r != "bytes=6-" {
+		t.Errorf("Range header should be sent to redirect target: %q", r)
+	}
+	if a := redirected[0].Header.Get("Authorization"); a != "" {
+		t.Errorf("API token should not be sent to redirect target: %q", a)
+	}
+}
+
+func TestResumeDownloadSizeMismatch(t *testing.T) {
+	rel := binaryRelease("v1.1.0")
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1.1.0 and extra bytes"))
+	})
+
+	dir, cleanupDir := newTestCacheDir(t)
+	defer cleanupDir()
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{CacheDir: dir})
+	_, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err == nil {
+		t.Fatal("Error should occur for size mismatch")
+	}
+	if !strings.Contains(err.Error(), "does not match to the size of asset") {
+		t.Error("Unexpected error:", err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be updated: %q", got)
+	}
+	if fs, _ := ioutil.ReadDir(dir); len(fs) != 0 {
+		t.Error("Broken download should be removed:", fs[0].Name())
+	}
+}
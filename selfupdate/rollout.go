@@ -0,0 +1,143 @@
+package selfupdate
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+// timeNow returns the current time. It is replaced in tests.
+var timeNow = time.Now
+
+// machineIDFiles are files which contain a stable ID of the machine on Linux systems.
+var machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// machineIDPath returns a path to the file to persist a generated machine ID. It is replaced in tests.
+var machineIDPath = func() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-github-selfupdate", "machine-id"), nil
+}
+
+// detectMachineID returns a stable ID of this machine. The machine ID of the system is used when available.
+// Otherwise a random ID is generated and persisted in the user config directory.
+func detectMachineID() (string, error) {
+	for _, f := range machineIDFiles {
+		if b, err := ioutil.ReadFile(f); err == nil {
+			if id := strings.TrimSpace(string(b)); id != "" {
+				return id, nil
+			}
+		}
+	}
+
+	path, err := machineIDPath()
+	if err != nil {
+		return "", err
+	}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(b)); id != "" {
+			return id, nil
+		}
+	}
+
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf[:])
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, []byte(id+"\n"), 0644); err != nil {
+		return "", err
+	}
+	log.Println("Generated machine ID for staged rollouts at", path)
+	return id, nil
+}
+
+// detectedMachineID is the machine ID detected once per process. It is shared by all updaters since it
+// identifies the machine rather than the updater.
+var detectedMachineID struct {
+	once sync.Once
+	id   string
+}
+
+func (up *Updater) getMachineID() string {
+	if up.machineID != "" {
+		return up.machineID
+	}
+	detectedMachineID.once.Do(func() {
+		id, err := detectMachineID()
+		if err != nil {
+			log.Println("Could not detect machine ID for staged rollouts:", err)
+			return
+		}
+		detectedMachineID.id = id
+	})
+	return detectedMachineID.id
+}
+
+// rolloutBucket maps the machine ID and the version to a stable value in [0, 100). The version is mixed
+// so that the same machines are not always the first to receive new releases.
+func rolloutBucket(machineID string, ver semver.Version) float64 {
+	h := sha256.Sum256([]byte(machineID + "@" + ver.String()))
+	return float64(binary.BigEndian.Uint64(h[:8])%10000) / 100
+}
+
+// rolledOut returns true when the release is rolled out to this machine. A release can be rolled out
+// to a part of machines gradually by 'rollout' (percentage of machines) and 'rollout_start' (RFC3339 time)
+// in the metadata of its release notes. Whether this machine is eligible is decided deterministically by
+// hashing the machine ID with the version.
+//
+//	<!-- selfupdate
+//	rollout: 25
+//	rollout_start: 2020-01-02T15:04:05Z
+//	-->
+func (up *Updater) rolledOut(rel *github.RepositoryRelease, ver semver.Version) bool {
+	meta := parseReleaseMetadata(rel.GetBody())
+
+	if s, ok := meta["rollout_start"]; ok {
+		start, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			log.Printf("Ignore invalid rollout_start %q in release %s: %s\n", s, rel.GetTagName(), err)
+		} else if timeNow().Before(start) {
+			log.Println("Rollout of", rel.GetTagName(), "has not started yet. It will start at", start)
+			return false
+		}
+	}
+
+	s, ok := meta["rollout"]
+	if !ok {
+		return true
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		log.Printf("Ignore invalid rollout %q in release %s: %s\n", s, rel.GetTagName(), err)
+		return true
+	}
+	if pct >= 100 {
+		return true
+	}
+
+	id := up.getMachineID()
+	if id == "" {
+		// When the machine cannot be identified, stay on the safe side
+		return false
+	}
+	bucket := rolloutBucket(id, ver)
+	log.Printf("Release %s is rolled out to %g%% of machines. This machine is in bucket %g\n", rel.GetTagName(), pct, bucket)
+	return bucket < pct
+}
@@ -0,0 +1,144 @@
+package selfupdate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func TestRolloutBucket(t *testing.T) {
+	v := semver.MustParse("1.2.3")
+	eligible := 0
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprintf("machine-%d", i)
+		b := rolloutBucket(id, v)
+		if b < 0 || b >= 100 {
+			t.Fatal("Bucket is out of range:", b)
+		}
+		if b != rolloutBucket(id, v) {
+			t.Fatal("Bucket is not deterministic for", id)
+		}
+		if b < 25 {
+			eligible++
+		}
+	}
+	if eligible < 400 || 600 < eligible {
+		t.Errorf("About 25%% of 2000 machines should be eligible but %d machines were", eligible)
+	}
+}
+
+// machineIDInBucket finds a machine ID whose bucket for the version is in [lo, hi).
+func machineIDInBucket(t *testing.T, ver string, lo, hi float64) string {
+	v := semver.MustParse(ver)
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("machine-%d", i)
+		if b := rolloutBucket(id, v); lo <= b && b < hi {
+			return id
+		}
+	}
+	t.Fatal("Machine ID was not found for bucket", lo, hi)
+	return ""
+}
+
+func TestDetectLatestWithStagedRollout(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	srv, _ := newTestAPIServer(t, []testRelease{
+		{tag: "v1.0.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", body: "<!-- selfupdate\nrollout: 25\nrollout_start: " + past + "\n-->", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", body: "<!-- selfupdate\nrollout: 100\nrollout_start: " + future + "\n-->", assets: []string{platformAsset("foo")}},
+	})
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		what      string
+		machineID string
+		want      string
+	}{
+		{"eligible", machineIDInBucket(t, "1.1.0", 0, 25), "1.1.0"},
+		{"not eligible", machineIDInBucket(t, "1.1.0", 25, 100), "1.0.0"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			up := newTestUpdater(t, srv, Config{MachineID: tc.machineID})
+			for i := 0; i < 3; i++ {
+				rel, ok, err := up.DetectLatest("owner/repo")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					t.Fatal("Release was not found")
+				}
+				if rel.Version.String() != tc.want {
+					t.Fatalf("Wanted %s but got %s", tc.want, rel.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestRolloutMetadata(t *testing.T) {
+	up := &Updater{machineID: machineIDInBucket(t, "1.0.0", 10, 11)}
+	v := semver.MustParse("1.0.0")
+	for _, tc := range []struct {
+		body string
+		want bool
+	}{
+		{"", true},
+		{"<!-- selfupdate\nrollout: 0\n-->", false},
+		{"<!-- selfupdate\nrollout: 10\n-->", false},
+		{"<!-- selfupdate\nrollout: 11%\n-->", true},
+		{"<!-- selfupdate\nrollout: 100\n-->", true},
+		{"<!-- selfupdate\nrollout: foo\n-->", true},
+		{"<!-- selfupdate\nrollout_start: 2000-01-01T00:00:00Z\n-->", true},
+		{"<!-- selfupdate\nrollout_start: 2999-01-01T00:00:00Z\n-->", false},
+		{"<!-- selfupdate\nrollout_start: tomorrow\n-->", true},
+	} {
+		rel := (&testRelease{tag: "v1.0.0", body: tc.body}).toGitHub(1)
+		if got := up.rolledOut(rel, v); got != tc.want {
+			t.Errorf("Wanted %v for %q but got %v", tc.want, tc.body, got)
+		}
+	}
+}
+
+func TestDetectMachineIDGeneratesPersistentID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selfupdate-machine-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	savedFiles, savedPath := machineIDFiles, machineIDPath
+	defer func() { machineIDFiles, machineIDPath = savedFiles, savedPath }()
+	machineIDFiles = []string{filepath.Join(dir, "not-existing")}
+	path := filepath.Join(dir, "config", "machine-id")
+	machineIDPath = func() (string, error) { return path, nil }
+
+	id, err := detectMachineID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 32 {
+		t.Fatal("Unexpected generated ID:", id)
+	}
+	id2, err := detectMachineID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != id2 {
+		t.Fatal("Generated ID was not persisted:", id, id2)
+	}
+
+	system := filepath.Join(dir, "machine-id")
+	if err := ioutil.WriteFile(system, []byte("system-id\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	machineIDFiles = []string{system}
+	if id, err := detectMachineID(); err != nil || id != "system-id" {
+		t.Fatal("System machine ID should be preferred:", id, err)
+	}
+}
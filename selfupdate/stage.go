@@ -0,0 +1,274 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/inconshreveable/go-update"
+)
+
+// stagedMarker is the content of the marker file written after an executable was staged entirely.
+type stagedMarker struct {
+	Version string `json:"version"`
+	Target  string `json:"target"`
+	SHA256  string `json:"sha256"`
+	// TargetSHA256 is the checksum of the command when it was staged. When the command was replaced after
+	// staging, for example by UpdateCommand, the staged executable is outdated and is discarded.
+	TargetSHA256 string `json:"target_sha256"`
+}
+
+// fileSHA256 returns the SHA-256 checksum of the file in hex.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stagingDir returns the directory to stage executables. The user cache directory is used by default.
+func (up *Updater) stagingDir() (string, error) {
+	if up.stagingDirectory != "" {
+		return up.stagingDirectory, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get user cache directory for staging: %s", err)
+	}
+	return filepath.Join(dir, "go-github-selfupdate", "staged"), nil
+}
+
+// stagedPaths returns paths to the staged executable and its marker for the command. They are named with the
+// command name and the hash of the command path so that commands with the same name in different directories
+// do not share them.
+func (up *Updater) stagedPaths(cmdPath string) (string, string, error) {
+	dir, err := up.stagingDir()
+	if err != nil {
+		return "", "", err
+	}
+	h := sha256.Sum256([]byte(cmdPath))
+	name := strings.TrimSuffix(filepath.Base(cmdPath), ".exe") + "-" + hex.EncodeToString(h[:])[:12]
+	return filepath.Join(dir, name), filepath.Join(dir, name+".json"), nil
+}
+
+// removeStaged removes the staged executable and its marker.
+func removeStaged(exe, marker string) {
+	os.Remove(marker)
+	os.Remove(exe)
+}
+
+// clearStaged removes the executable staged for the command. It is called after the command was updated
+// by other ways than ApplyStagedCommand since the staged executable is no longer needed.
+func (up *Updater) clearStaged(cmdPath string) {
+	exe, marker, err := up.stagedPaths(cmdPath)
+	if err != nil {
+		return
+	}
+	b, err := ioutil.ReadFile(marker)
+	if err != nil {
+		return
+	}
+	var m stagedMarker
+	if err := json.Unmarshal(b, &m); err == nil && m.Target != cmdPath {
+		return
+	}
+	log.Println("Removing staged executable", exe, "since", cmdPath, "was updated")
+	removeStaged(exe, marker)
+}
+
+// StageCommand downloads the asset of the release, validates it and extracts the executable for the command into
+// the staging directory (Config.StagingDir) without replacing the command. A marker file which records the version
+// and the checksums of the staged executable and the current command is written at last. The staged executable replaces the command when
+// ApplyStagedCommand is called later, for example on the next start of the command.
+func (up *Updater) StageCommand(rel *Release, cmdPath string) error {
+	cmdPath, err := resolveCommandPath(cmdPath)
+	if err != nil {
+		return err
+	}
+	exe, marker, err := up.stagedPaths(cmdPath)
+	if err != nil {
+		return err
+	}
+	target, err := fileSHA256(cmdPath)
+	if err != nil {
+		return fmt.Errorf("Failed to calculate checksum of %s: %s", cmdPath, err)
+	}
+
+	f, _, cleanup, err := up.downloadVerifiedAsset(rel)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	cmd := filepath.Base(cmdPath)
+	src, err := UncompressCommand(f, rel.AssetURL, cmd)
+	if err != nil {
+		return err
+	}
+	src, err = limitSize(src, "executable", -1, up.maxExecutableBytes)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(exe)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Failed to create staging directory %s: %s", dir, err)
+	}
+	// Remove the previously staged executable at first so that it is never applied with the new marker
+	removeStaged(exe, marker)
+
+	tmp, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file in staging directory: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), src)
+	tmp.Close()
+	if err != nil {
+		if e, ok := err.(*SizeLimitError); ok {
+			return e
+		}
+		return fmt.Errorf("Failed to write staged executable: %s", err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("Failed to stage executable %s: %s", exe, err)
+	}
+
+	b, err := json.Marshal(&stagedMarker{
+		Version:      rel.Version.String(),
+		Target:       cmdPath,
+		SHA256:       hex.EncodeToString(h.Sum(nil)),
+		TargetSHA256: target,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(marker+".tmp", b, 0644); err != nil {
+		return fmt.Errorf("Failed to write marker of staged executable: %s", err)
+	}
+	if err := os.Rename(marker+".tmp", marker); err != nil {
+		return fmt.Errorf("Failed to write marker of staged executable: %s", err)
+	}
+
+	log.Println("Staged version", rel.Version, "of", cmdPath, "at", exe)
+	return nil
+}
+
+// Stage downloads the release and stages the executable to replace the running executable. Please see
+// StageCommand for more details.
+func (up *Updater) Stage(rel *Release) error {
+	cmdPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return up.StageCommand(rel, cmdPath)
+}
+
+// ApplyStagedCommand replaces the command with the executable staged by StageCommand. The staged executable is
+// verified against the checksum recorded in its marker again before replacing the command, and the command is
+// replaced atomically. When the command was changed after staging (e.g. it was updated to a newer version by
+// UpdateCommand), the staged executable is discarded so that the command is never downgraded to it. It returns
+// the version of the applied executable, or nil when nothing is applied. The staged executable is removed after
+// it is applied, discarded or when it is broken.
+func (up *Updater) ApplyStagedCommand(cmdPath string) (*semver.Version, error) {
+	cmdPath, err := resolveCommandPath(cmdPath)
+	if err != nil {
+		return nil, err
+	}
+	exe, marker, err := up.stagedPaths(cmdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(marker)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to read marker of staged executable: %s", err)
+	}
+	var m stagedMarker
+	if err := json.Unmarshal(b, &m); err != nil {
+		removeStaged(exe, marker)
+		return nil, fmt.Errorf("Broken marker of staged executable %s: %s", marker, err)
+	}
+	if m.Target != cmdPath {
+		log.Println("Staged executable", exe, "is for", m.Target, "but not for", cmdPath, ". Ignoring it")
+		return nil, nil
+	}
+	v, err := semver.Parse(m.Version)
+	if err != nil {
+		removeStaged(exe, marker)
+		return nil, fmt.Errorf("Invalid version in marker of staged executable %s: %s", marker, err)
+	}
+	sum, err := hex.DecodeString(m.SHA256)
+	if err != nil || len(sum) != sha256.Size {
+		removeStaged(exe, marker)
+		return nil, fmt.Errorf("Invalid checksum in marker of staged executable %s: %q", marker, m.SHA256)
+	}
+
+	if current, err := fileSHA256(cmdPath); err != nil || current != m.TargetSHA256 {
+		log.Println("Discarding staged version", v, "since", cmdPath, "was changed after it was staged")
+		removeStaged(exe, marker)
+		return nil, nil
+	}
+
+	f, err := os.Open(exe)
+	if err != nil {
+		removeStaged(exe, marker)
+		return nil, fmt.Errorf("Failed to open staged executable: %s", err)
+	}
+	defer f.Close()
+
+	log.Println("Will update", cmdPath, "to staged version", v)
+	if err := update.Apply(f, update.Options{
+		TargetPath: cmdPath,
+		Checksum:   sum,
+	}); err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return nil, fmt.Errorf("Failed to rollback from bad update: %v", rerr)
+		}
+		removeStaged(exe, marker)
+		return nil, fmt.Errorf("Failed to apply staged executable %s: %v", exe, err)
+	}
+
+	removeStaged(exe, marker)
+	return &v, nil
+}
+
+// ApplyStaged replaces the running executable with the staged executable. It should be called early in main
+// so that the new executable is used from the next start. Please see ApplyStagedCommand for more details.
+func (up *Updater) ApplyStaged() (*semver.Version, error) {
+	cmdPath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return up.ApplyStagedCommand(cmdPath)
+}
+
+// Stage downloads the release and stages the executable to replace the running executable.
+// This function is a shortcut version of updater.Stage.
+func Stage(rel *Release) error {
+	return DefaultUpdater().Stage(rel)
+}
+
+// ApplyStaged replaces the running executable with the staged executable.
+// This function is a shortcut version of updater.ApplyStaged.
+func ApplyStaged() (*semver.Version, error) {
+	return DefaultUpdater().ApplyStaged()
+}
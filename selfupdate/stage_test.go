@@ -0,0 +1,256 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestStageAndApplyStagedCommand(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}, StagingDir: dir})
+
+	v, err := up.ApplyStagedCommand(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Fatal("Nothing should be applied when nothing is staged:", v)
+	}
+
+	latest, found, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("Release was not found")
+	}
+	if err := up.StageCommand(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Fatalf("Executable should not be replaced by staging: %q", got)
+	}
+
+	v, err = up.ApplyStagedCommand(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v == nil || !v.Equals(semver.MustParse("1.1.0")) {
+		t.Fatal("Staged version should be applied:", v)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable should be replaced with staged one: %q", got)
+	}
+	fs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 0 {
+		t.Error("Staged files should be removed after applied:", fs[0].Name())
+	}
+
+	v, err = up.ApplyStagedCommand(exe)
+	if err != nil || v != nil {
+		t.Error("Staged executable should be applied only once:", v, err)
+	}
+}
+
+func TestApplyStagedCommandBrokenExecutable(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+
+	up := newTestUpdater(t, srv, Config{StagingDir: dir})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.StageCommand(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, _, err := up.stagedPaths(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(staged, []byte("v6.6.6"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = up.ApplyStagedCommand(exe)
+	if err == nil {
+		t.Fatal("Error should occur when staged executable was modified")
+	}
+	if !strings.Contains(err.Error(), "Failed to apply staged executable") {
+		t.Error("Unexpected error:", err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be replaced with broken staged one: %q", got)
+	}
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Error("Broken staged executable should be removed:", err)
+	}
+}
+
+func TestApplyStagedCommandForOtherTarget(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+	other, cleanupOther := newTestExecutable(t, "v1.0.0")
+	defer cleanupOther()
+	if filepath.Base(exe) != filepath.Base(other) {
+		t.Fatal("Test executables should have the same name")
+	}
+
+	up := newTestUpdater(t, srv, Config{StagingDir: dir})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.StageCommand(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := up.ApplyStagedCommand(other)
+	if err != nil || v != nil {
+		t.Error("Executable staged for other path should not be applied:", v, err)
+	}
+	if got := readTestExecutable(t, other); got != "v1.0.0" {
+		t.Errorf("Other executable should not be replaced: %q", got)
+	}
+}
+
+func TestApplyStagedCommandAfterCommandChanged(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+
+	up := newTestUpdater(t, srv, Config{StagingDir: dir})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.StageCommand(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+
+	// The command was updated to a newer version by other way after staging
+	if err := ioutil.WriteFile(exe, []byte("v1.2.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	v, err := up.ApplyStagedCommand(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Error("Outdated staged executable should not be applied:", v)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.2.0" {
+		t.Errorf("Executable should not be downgraded to staged one: %q", got)
+	}
+	fs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 0 {
+		t.Error("Outdated staged files should be removed:", fs[0].Name())
+	}
+}
+
+func TestUpdateToClearsStagedCommand(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+
+	up := newTestUpdater(t, srv, Config{StagingDir: dir})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.StageCommand(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+	if err := up.UpdateTo(latest, exe); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Fatalf("Executable was not updated: %q", got)
+	}
+	fs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 0 {
+		t.Error("Staged files should be removed after update:", fs[0].Name())
+	}
+}
+
+func TestStageCommandsWithSameName(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+	defer cleanupExe()
+	other, cleanupOther := newTestExecutable(t, "v1.0.0")
+	defer cleanupOther()
+
+	up := newTestUpdater(t, srv, Config{StagingDir: dir})
+	latest, _, err := up.DetectLatest("owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{exe, other} {
+		if err := up.StageCommand(latest, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range []string{exe, other} {
+		v, err := up.ApplyStagedCommand(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil || !v.Equals(semver.MustParse("1.1.0")) {
+			t.Errorf("Staged executable for %s should be applied: %v", p, v)
+		}
+		if got := readTestExecutable(t, p); got != "v1.1.0" {
+			t.Errorf("Executable %s should be replaced with staged one: %q", p, got)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ulikunitz/xz"
 	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -56,23 +57,122 @@ func unarchiveTar(src io.Reader, url, cmd string) (io.Reader, error) {
 	return nil, fmt.Errorf("File '%s' for the command is not found in %s", cmd, url)
 }
 
+// readerAt returns the source as io.ReaderAt with its size when the source supports random access such
+// as a file.
+func readerAt(src io.Reader) (io.ReaderAt, int64, bool) {
+	switch r := src.(type) {
+	case *os.File:
+		s, err := r.Stat()
+		if err != nil || !s.Mode().IsRegular() {
+			return nil, 0, false
+		}
+		return r, s.Size(), true
+	case interface {
+		io.ReaderAt
+		Size() int64
+	}:
+		return r, r.Size(), true
+	default:
+		return nil, 0, false
+	}
+}
+
+// isTar returns true when the header of the stream is a header of tar archive.
+func isTar(header []byte) bool {
+	return len(header) >= 262 && string(header[257:262]) == "ustar"
+}
+
+// detectArchiveFormat detects the archive and compression format of the file from its content. It returns
+// the file extension for the format such as '.zip' or '.tar.gz', or an empty string when the file is not
+// compressed. The file is rewound to the beginning.
+func detectArchiveFormat(f io.ReadSeeker) (string, error) {
+	magic := make([]byte, 6)
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("Failed to read header of file: %s", err)
+	}
+	magic = magic[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("Failed to rewind file: %s", err)
+	}
+
+	// Peek the header of the uncompressed content to know whether it is a tar archive
+	peek := func(r io.Reader) []byte {
+		b := make([]byte, 262)
+		n, _ := io.ReadFull(r, b)
+		return b[:n]
+	}
+
+	format := ""
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		format = ".zip"
+	case bytes.HasPrefix(magic, []byte("\x1f\x8b")):
+		format = ".gz"
+		if gz, err := gzip.NewReader(f); err == nil && isTar(peek(gz)) {
+			format = ".tar.gz"
+		}
+	case bytes.HasPrefix(magic, []byte("\xfd7zXZ\x00")):
+		format = ".xz"
+		if xzip, err := xz.NewReader(f); err == nil && isTar(peek(xzip)) {
+			format = ".tar.xz"
+		}
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("Failed to rewind file: %s", err)
+	}
+	return format, nil
+}
+
+// archiveFormat returns the archive and compression format of the asset from the file extension of its URL
+// in the same form as detectArchiveFormat. It returns an empty string when the asset is not compressed.
+func archiveFormat(url string) string {
+	switch {
+	case strings.HasSuffix(url, ".zip"):
+		return ".zip"
+	case strings.HasSuffix(url, ".tar.gz"), strings.HasSuffix(url, ".tgz"):
+		return ".tar.gz"
+	case strings.HasSuffix(url, ".gzip"), strings.HasSuffix(url, ".gz"):
+		return ".gz"
+	case strings.HasSuffix(url, ".tar.xz"):
+		return ".tar.xz"
+	case strings.HasSuffix(url, ".xz"):
+		return ".xz"
+	default:
+		return ""
+	}
+}
+
 // UncompressCommand uncompresses the given source. Archive and compression format is
 // automatically detected from 'url' parameter, which represents the URL of asset.
 // This returns a reader for the uncompressed command given by 'cmd'. '.zip',
-// '.tar.gz', '.tar.xz', '.tgz', '.gz' and '.xz' are supported.
+// '.tar.gz', '.tar.xz', '.tgz', '.gz' and '.xz' are supported. Since zip format requires random
+// access, a zip file is read into memory unless the source is a file or implements io.ReaderAt and
+// Size() (e.g. *bytes.Reader).
 func UncompressCommand(src io.Reader, url, cmd string) (io.Reader, error) {
-	if strings.HasSuffix(url, ".zip") {
+	return uncompressFormat(src, archiveFormat(url), url, cmd)
+}
+
+// uncompressFormat uncompresses the given source in the format returned from archiveFormat or
+// detectArchiveFormat. 'url' is only used for messages.
+func uncompressFormat(src io.Reader, format, url, cmd string) (io.Reader, error) {
+	switch format {
+	case ".zip":
 		log.Println("Uncompressing zip file", url)
 
-		// Zip format requires its file size for uncompressing.
-		// So we need to read the HTTP response into a buffer at first.
-		buf, err := ioutil.ReadAll(src)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to create buffer for zip file: %s", err)
+		// Zip format requires random access and its file size for uncompressing.
+		// When the source does not support them, we need to read it into a buffer at first.
+		r, size, ok := readerAt(src)
+		if !ok {
+			buf, err := ioutil.ReadAll(src)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to create buffer for zip file: %s", err)
+			}
+			r, size = bytes.NewReader(buf), int64(len(buf))
 		}
 
-		r := bytes.NewReader(buf)
-		z, err := zip.NewReader(r, r.Size())
+		z, err := zip.NewReader(r, size)
 		if err != nil {
 			return nil, fmt.Errorf("Failed to uncompress zip file: %s", err)
 		}
@@ -86,7 +186,7 @@ func UncompressCommand(src io.Reader, url, cmd string) (io.Reader, error) {
 		}
 
 		return nil, fmt.Errorf("File '%s' for the command is not found in %s", cmd, url)
-	} else if strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz") {
+	case ".tar.gz":
 		log.Println("Uncompressing tar.gz file", url)
 
 		gz, err := gzip.NewReader(src)
@@ -95,7 +195,7 @@ func UncompressCommand(src io.Reader, url, cmd string) (io.Reader, error) {
 		}
 
 		return unarchiveTar(gz, url, cmd)
-	} else if strings.HasSuffix(url, ".gzip") || strings.HasSuffix(url, ".gz") {
+	case ".gz":
 		log.Println("Uncompressing gzip file", url)
 
 		r, err := gzip.NewReader(src)
@@ -110,7 +210,7 @@ func UncompressCommand(src io.Reader, url, cmd string) (io.Reader, error) {
 
 		log.Println("Executable file", name, "was found in gzip file")
 		return r, nil
-	} else if strings.HasSuffix(url, ".tar.xz") {
+	case ".tar.xz":
 		log.Println("Uncompressing tar.xz file", url)
 
 		xzip, err := xz.NewReader(src)
@@ -119,7 +219,7 @@ func UncompressCommand(src io.Reader, url, cmd string) (io.Reader, error) {
 		}
 
 		return unarchiveTar(xzip, url, cmd)
-	} else if strings.HasSuffix(url, ".xz") {
+	case ".xz":
 		log.Println("Uncompressing xzip file", url)
 
 		xzip, err := xz.NewReader(src)
@@ -2,6 +2,7 @@ package selfupdate
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -131,3 +132,65 @@ func TestTargetNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestUncompressZipFromStream(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []io.Reader{
+		bytes.NewReader(b),
+		io.MultiReader(bytes.NewReader(b)), // Does not support random access
+	} {
+		r, err := UncompressCommand(src, "https://github.com/foo/bar/releases/download/v1.2.3/bar.zip", "bar")
+		if err != nil {
+			t.Fatalf("Failed to uncompress zip from %T: %s", src, err)
+		}
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "this is test\n" {
+			t.Errorf("Uncompressing zip from %T failed into unexpected content: %q", src, out)
+		}
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	for _, tc := range []struct {
+		file string
+		want string
+	}{
+		{"foo.zip", ".zip"},
+		{"single-file.zip", ".zip"},
+		{"foo.tar.gz", ".tar.gz"},
+		{"foo.tgz", ".tar.gz"},
+		{"single-file.gz", ".gz"},
+		{"single-file.gzip", ".gz"},
+		{"foo.tar.xz", ".tar.xz"},
+		{"single-file.xz", ".xz"},
+		{"fake-executable", ""},
+		{"foo.zip.sha256", ""},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := detectArchiveFormat(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("Wanted format %q but got %q", tc.want, got)
+			}
+			if off, _ := f.Seek(0, io.SeekCurrent); off != 0 {
+				t.Error("File should be rewound but offset is", off)
+			}
+			if got := archiveFormat(tc.file); got != tc.want {
+				t.Errorf("Wanted format %q from file name but got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package selfupdate
 import (
 	"bytes"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -15,23 +16,41 @@ import (
 	"github.com/inconshreveable/go-update"
 )
 
-func uncompressAndUpdate(src io.Reader, assetURL, cmdPath string) error {
+func (up *Updater) uncompressAndUpdate(src io.Reader, format, assetURL, cmdPath string) error {
 	_, cmd := filepath.Split(cmdPath)
-	asset, err := UncompressCommand(src, assetURL, cmd)
+	asset, err := uncompressFormat(src, format, assetURL, cmd)
+	if err != nil {
+		return err
+	}
+	asset, err = limitSize(asset, "executable", -1, up.maxExecutableBytes)
 	if err != nil {
 		return err
 	}
 
+	// Applying starts when the executable was extracted entirely
+	extracted := up.newProgressReader(asset, PhaseExtract, -1)
+	size := int64(-1)
+	extracted.onEOF = func(done int64) {
+		size = done
+		up.reportProgress(PhaseApply, 0, size)
+	}
+
 	log.Println("Will update", cmdPath, "to the latest downloaded from", assetURL)
-	return update.Apply(asset, update.Options{
+	if err := update.Apply(extracted, update.Options{
 		TargetPath: cmdPath,
-	})
+	}); err != nil {
+		return err
+	}
+	up.reportProgress(PhaseApply, size, size)
+	return nil
 }
 
-func (up *Updater) downloadDirectlyFromURL(assetURL string) (io.ReadCloser, error) {
+// downloadDirectlyFromURL downloads the asset from the URL. It also returns the size of the asset from
+// Content-Length of the response. The size is -1 when it is unknown.
+func (up *Updater) downloadDirectlyFromURL(assetURL string) (io.ReadCloser, int64, error) {
 	req, err := http.NewRequest("GET", assetURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create HTTP request to %s: %s", assetURL, err)
+		return nil, 0, fmt.Errorf("Failed to create HTTP request to %s: %s", assetURL, err)
 	}
 
 	req.Header.Add("Accept", "application/octet-stream")
@@ -42,72 +61,342 @@ func (up *Updater) downloadDirectlyFromURL(assetURL string) (io.ReadCloser, erro
 	// Use default HTTP client instead.
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to download a release file from %s: %s", assetURL, err)
+		return nil, 0, fmt.Errorf("Failed to download a release file from %s: %s", assetURL, err)
 	}
 
 	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("Failed to download a release file from %s: Not successful status %d", assetURL, res.StatusCode)
+		return nil, 0, fmt.Errorf("Failed to download a release file from %s: Not successful status %d", assetURL, res.StatusCode)
 	}
 
-	return res.Body, nil
+	return res.Body, res.ContentLength, nil
 }
 
-// UpdateTo downloads an executable from GitHub Releases API and replace current binary with the downloaded one.
-// It downloads a release asset via GitHub Releases API so this function is available for update releases on private repository.
-// If a redirect occurs, it fallbacks into directly downloading from the redirect URL.
-func (up *Updater) UpdateTo(rel *Release, cmdPath string) error {
+// downloadReleaseAsset downloads the asset of the release via GitHub Releases API. 'kind' describes the
+// asset in error messages. It also returns the size of the asset when it is known. Otherwise the size is -1.
+func (up *Updater) downloadReleaseAsset(rel *Release, id int64, kind string) (io.ReadCloser, int64, error) {
 	var client http.Client
-	src, redirectURL, err := up.api.Repositories.DownloadReleaseAsset(up.apiCtx, rel.RepoOwner, rel.RepoName, rel.AssetID, &client)
+	src, redirectURL, err := up.api.Repositories.DownloadReleaseAsset(up.apiCtx, rel.RepoOwner, rel.RepoName, id, &client)
 	if err != nil {
-		return fmt.Errorf("Failed to call GitHub Releases API for getting an asset(ID: %d) for repository '%s/%s': %s", rel.AssetID, rel.RepoOwner, rel.RepoName, err)
+		return nil, 0, fmt.Errorf("Failed to call GitHub Releases API for getting %s(ID: %d) for repository '%s/%s': %s", kind, id, rel.RepoOwner, rel.RepoName, err)
 	}
 	if redirectURL != "" {
-		log.Println("Redirect URL was returned while trying to download a release asset from GitHub API. Falling back to downloading from asset URL directly:", redirectURL)
-		src, err = up.downloadDirectlyFromURL(redirectURL)
-		if err != nil {
-			return err
+		log.Println("Redirect URL was returned while trying to download", kind, "from GitHub API. Falling back to downloading from asset URL directly:", redirectURL)
+		return up.downloadDirectlyFromURL(redirectURL)
+	}
+	return src, -1, nil
+}
+
+// downloadToFile writes the content of the source to a temporary file instead of keeping it in memory.
+// When the hash is not nil, the digest of the content is calculated while writing. 'size' is the expected size
+// of the content. It is reported as progress and the number of downloaded bytes is verified against it. It is
+// -1 when the size is unknown. The download fails with SizeLimitError when it exceeds Config.MaxDownloadBytes.
+// The returned file is rewound to the beginning. The caller must call the returned function to close and
+// remove the file.
+func (up *Updater) downloadToFile(src io.Reader, size int64, h hash.Hash) (*os.File, func(), error) {
+	src, err := limitSize(src, "asset", size, up.maxDownloadBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := ioutil.TempFile("", "selfupdate-download-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create temporary file for downloading asset: %s", err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	var w io.Writer = f
+	if h != nil {
+		w = io.MultiWriter(f, h)
+	}
+	n, err := io.Copy(w, up.newProgressReader(src, PhaseDownload, size))
+	if err != nil {
+		cleanup()
+		if e, ok := err.(*SizeLimitError); ok {
+			return nil, nil, e
 		}
+		return nil, nil, fmt.Errorf("Failed reading asset body: %v", err)
+	}
+	if err := checkDownloadSize(n, size); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Failed to rewind downloaded asset file %s: %s", f.Name(), err)
+	}
+	return f, cleanup, nil
+}
+
+// newHash returns a hash to calculate the digest of the asset while downloading it. It returns nil when
+// the validator does not implement HashValidator.
+func (up *Updater) newHash() hash.Hash {
+	if hv, ok := up.validator.(HashValidator); ok {
+		return hv.NewHash()
+	}
+	return nil
+}
+
+// validateAsset validates the downloaded asset file with the validator and reports its progress. It does
+// nothing when no validator is set.
+func (up *Updater) validateAsset(f *os.File, h hash.Hash, validationData []byte) error {
+	if up.validator == nil {
+		return nil
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("Failed to stat downloaded asset file %s: %s", f.Name(), err)
+	}
+	up.reportProgress(PhaseValidate, 0, stat.Size())
+	if err := up.validate(f, h, validationData); err != nil {
+		return fmt.Errorf("Failed validating asset content: %v", err)
+	}
+	up.reportProgress(PhaseValidate, stat.Size(), stat.Size())
+	return nil
+}
+
+// selectValidationData selects the validation data for the asset named 'asset' from the content of the
+// validation asset when the validator implements ValidationAssetSelector.
+func (up *Updater) selectValidationData(asset string, data []byte) ([]byte, error) {
+	s, ok := up.validator.(ValidationAssetSelector)
+	if !ok {
+		return data, nil
+	}
+	selected, err := s.SelectValidationData(asset, data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed validating asset content: %v", err)
+	}
+	return selected, nil
+}
+
+// validate validates the downloaded asset file against the validation asset. When the validator implements
+// HashValidator, the digest calculated while downloading is used. Otherwise the file is read into memory.
+func (up *Updater) validate(f *os.File, h hash.Hash, validationData []byte) error {
+	if hv, ok := up.validator.(HashValidator); ok && h != nil {
+		return hv.ValidateHash(h.Sum(nil), validationData)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("Failed reading downloaded asset %s: %s", f.Name(), err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Failed to rewind downloaded asset file %s: %s", f.Name(), err)
+	}
+	return up.validator.Validate(data, validationData)
+}
+
+// downloadAsset downloads the asset of the release into a temporary file. When the cache directory is set,
+// the download is resumable.
+func (up *Updater) downloadAsset(rel *Release, h hash.Hash) (*os.File, func(), error) {
+	if up.cacheDir != "" {
+		return up.downloadResumable(rel, h)
+	}
+
+	src, size, err := up.downloadReleaseAsset(rel, rel.AssetID, "an asset")
+	if err != nil {
+		return nil, nil, err
 	}
 	defer src.Close()
+	if rel.AssetByteSize > 0 {
+		size = int64(rel.AssetByteSize)
+	}
+	return up.downloadToFile(src, size, h)
+}
 
-	data, err := ioutil.ReadAll(src)
+// UpdateTo downloads an executable from GitHub Releases API and replace current binary with the downloaded one.
+// It downloads a release asset via GitHub Releases API so this function is available for update releases on private repository.
+// If a redirect occurs, it fallbacks into directly downloading from the redirect URL.
+// Assets of draft releases detected with Config.IncludeDrafts are also downloaded via the API with the API token.
+// The asset is streamed into a temporary file and is validated with the digest calculated while downloading it.
+// When Config.CacheDir is set, an interrupted download is resumed from where it stopped on the next call.
+// When rel.Patch is set, the binary delta asset is applied to the current executable instead. If applying
+// the patch or verifying the patched executable fails, the full asset is downloaded.
+// The executable staged for the command by StageCommand is removed after the command was updated.
+func (up *Updater) UpdateTo(rel *Release, cmdPath string) error {
+	if rel.Draft {
+		log.Println("Downloading an asset of draft release", rel.Version, "via GitHub Releases API")
+	}
+
+	if rel.Patch != nil {
+		err := up.updateWithPatch(rel, rel.Patch, cmdPath)
+		if err == nil {
+			up.clearStaged(cmdPath)
+			return nil
+		}
+		log.Println("Could not update with patch. Falling back to the full asset:", err)
+	}
+
+	f, _, cleanup, err := up.downloadVerifiedAsset(rel)
 	if err != nil {
-		return fmt.Errorf("Failed reading asset body: %v", err)
+		return err
 	}
+	defer cleanup()
 
-	if up.validator == nil {
-		return uncompressAndUpdate(bytes.NewReader(data), rel.AssetURL, cmdPath)
+	if err := up.uncompressAndUpdate(f, archiveFormat(rel.AssetURL), rel.AssetURL, cmdPath); err != nil {
+		return err
 	}
+	up.clearStaged(cmdPath)
+	return nil
+}
 
-	validationSrc, validationRedirectURL, err := up.api.Repositories.DownloadReleaseAsset(up.apiCtx, rel.RepoOwner, rel.RepoName, rel.ValidationAssetID, &client)
+// downloadVerifiedAsset downloads the asset of the release and validates it with the validator. The asset is
+// taken from the shared cache, mirrors or GitHub in this order. It also returns the content of the validation
+// asset (nil when no validator is set). The caller must call the returned function to release the file.
+func (up *Updater) downloadVerifiedAsset(rel *Release) (*os.File, []byte, func(), error) {
+	if err := checkSizeLimit("asset", int64(rel.AssetByteSize), up.maxDownloadBytes); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var data, validationData []byte
+	if up.validator != nil {
+		var err error
+		data, err = up.downloadValidationAsset(rel)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		validationData, err = up.selectValidationData(rel.AssetName, data)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	f, cleanup, err := up.downloadAndValidateAsset(rel, validationData)
 	if err != nil {
-		return fmt.Errorf("Failed to call GitHub Releases API for getting an validation asset(ID: %d) for repository '%s/%s': %s", rel.ValidationAssetID, rel.RepoOwner, rel.RepoName, err)
+		return nil, nil, nil, err
+	}
+	return f, data, cleanup, nil
+}
+
+// downloadAndValidateAsset downloads the asset of the release and validates it against the validation data
+// downloaded in advance.
+func (up *Updater) downloadAndValidateAsset(rel *Release, validationData []byte) (*os.File, func(), error) {
+	f, cleanup := up.cachedAsset(rel, validationData)
+	if f != nil {
+		return f, cleanup, nil
 	}
-	if validationRedirectURL != "" {
-		log.Println("Redirect URL was returned while trying to download a release validation asset from GitHub API. Falling back to downloading from asset URL directly:", redirectURL)
-		validationSrc, err = up.downloadDirectlyFromURL(validationRedirectURL)
+
+	f, cleanup = up.downloadFromMirrors(rel, validationData)
+	if f == nil {
+		h := up.newHash()
+		var err error
+		f, cleanup, err = up.downloadAsset(rel, h)
 		if err != nil {
-			return err
+			return nil, nil, err
+		}
+		if err := up.validateAsset(f, h, validationData); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	up.storeAsset(up.cacheKey(rel, rel.AssetID), f)
+	return f, cleanup, nil
+}
+
+// downloadValidationAsset downloads the validation asset of the release. When the shared cache is enabled, it is
+// looked up in the cache at first and stored in the cache after downloading.
+func (up *Updater) downloadValidationAsset(rel *Release) ([]byte, error) {
+	if up.cache != nil {
+		if b, ok := up.cache.readAll(up.cacheKey(rel, rel.ValidationAssetID)); ok {
+			return b, nil
 		}
 	}
 
-	defer validationSrc.Close()
+	src, _, err := up.downloadReleaseAsset(rel, rel.ValidationAssetID, "an validation asset")
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
 
-	validationData, err := ioutil.ReadAll(validationSrc)
+	data, err := ioutil.ReadAll(src)
 	if err != nil {
-		return fmt.Errorf("Failed reading validation asset body: %v", err)
+		return nil, fmt.Errorf("Failed reading validation asset body: %v", err)
 	}
 
-	if err := up.validator.Validate(data, validationData); err != nil {
-		return fmt.Errorf("Failed validating asset content: %v", err)
+	if up.cache != nil {
+		if err := up.cache.put(up.cacheKey(rel, rel.ValidationAssetID), bytes.NewReader(data)); err != nil {
+			log.Println("Could not store validation asset in cache:", err)
+		}
+	}
+	return data, nil
+}
+
+// cachedAsset returns the asset of the release in the shared cache. The cached asset is validated as well as
+// a downloaded asset. It returns nil when the asset is not cached or is invalid. The caller must call the
+// returned function to close the file.
+func (up *Updater) cachedAsset(rel *Release, validationData []byte) (*os.File, func()) {
+	if up.cache == nil {
+		return nil, nil
+	}
+	f, ok := up.cache.get(up.cacheKey(rel, rel.AssetID), int64(rel.AssetByteSize))
+	if !ok {
+		return nil, nil
 	}
 
-	return uncompressAndUpdate(bytes.NewReader(data), rel.AssetURL, cmdPath)
+	h := up.newHash()
+	if h != nil {
+		if _, err := io.Copy(h, f); err != nil {
+			f.Close()
+			return nil, nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil
+		}
+	}
+	if err := up.validateAsset(f, h, validationData); err != nil {
+		log.Println("Cached asset was rejected:", err)
+		f.Close()
+		return nil, nil
+	}
+	return f, func() { f.Close() }
 }
 
-// UpdateCommand updates a given command binary to the latest version.
-// 'slug' represents 'owner/name' repository on GitHub and 'current' means the current version.
-func (up *Updater) UpdateCommand(cmdPath string, current semver.Version, slug string) (*Release, error) {
+// cacheKey returns the key of the asset of the release in the shared cache. Since the cache is shared by tools
+// which may use different GitHub hosts, the key includes the API host and the repository as well as the asset ID.
+func (up *Updater) cacheKey(rel *Release, id int64) string {
+	return fmt.Sprintf("%s/%s/%s/%d", up.api.BaseURL.Host, rel.RepoOwner, rel.RepoName, id)
+}
+
+// storeAsset stores the downloaded asset file in the shared cache when it is enabled. The file is rewound
+// to the beginning after storing it.
+func (up *Updater) storeAsset(key string, f *os.File) {
+	if up.cache == nil {
+		return
+	}
+	if err := up.cache.put(key, f); err != nil {
+		log.Println("Could not store asset in cache:", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		log.Println("Could not rewind downloaded asset:", err)
+	}
+}
+
+// UpdateResult represents the result of UpdateCommand and UpdateSelf. It embeds the release which the
+// command was updated to. When the command was not updated because it is up-to-date, the embedded release
+// is the release of the current version. In other cases where the command was not updated, the embedded
+// release only has the current version.
+type UpdateResult struct {
+	*Release
+	// Status tells what actually happened. It is Updated when the command was updated. Otherwise it tells
+	// why the update was skipped.
+	Status UpdateStatus
+	// Decision is the decision of the update policy configured in Config.Policy.
+	Decision PolicyDecision
+	// Pending is the release which would be the latest but was ignored because it was published more recently
+	// than Config.MinReleaseAge. It is nil when no release is pending.
+	Pending *Release
+	// Changes are releases between the previous version and the updated version including the updated one,
+	// sorted from the oldest to the newest. They are set only when the command was updated. See
+	// CheckResult.Changes for more details.
+	Changes []*Release
+	// Reason explains why the update was applied or skipped.
+	Reason string
+}
+
+// resolveCommandPath returns the path to the executable file of the command. '.exe' is added on Windows and
+// a symbolic link is resolved.
+func resolveCommandPath(cmdPath string) (string, error) {
 	if runtime.GOOS == "windows" && !strings.HasSuffix(cmdPath, ".exe") {
 		// Ensure to add '.exe' to given path on Windows
 		cmdPath = cmdPath + ".exe"
@@ -115,38 +404,75 @@ func (up *Updater) UpdateCommand(cmdPath string, current semver.Version, slug st
 
 	stat, err := os.Lstat(cmdPath)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to stat '%s'. File may not exist: %s", cmdPath, err)
+		return "", fmt.Errorf("Failed to stat '%s'. File may not exist: %s", cmdPath, err)
 	}
 	if stat.Mode()&os.ModeSymlink != 0 {
 		p, err := filepath.EvalSymlinks(cmdPath)
 		if err != nil {
-			return nil, fmt.Errorf("Failed to resolve symlink '%s' for executable: %s", cmdPath, err)
+			return "", fmt.Errorf("Failed to resolve symlink '%s' for executable: %s", cmdPath, err)
 		}
 		cmdPath = p
 	}
+	return cmdPath, nil
+}
 
-	rel, ok, err := up.DetectLatest(slug)
+// UpdateCommand updates a given command binary to the latest version.
+// 'slug' represents 'owner/name' repository on GitHub and 'current' means the current version.
+// The latest release allowed by the update policy is selected. What happened and the decision of the
+// policy are set to the returned result. When the current version was yanked, it is replaced with the
+// latest release which is not yanked even if the release is older than the current version.
+func (up *Updater) UpdateCommand(cmdPath string, current semver.Version, slug string) (*UpdateResult, error) {
+	cmdPath, err := resolveCommandPath(cmdPath)
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		log.Println("No release detected. Current version is considered up-to-date")
-		return &Release{Version: current}, nil
+
+	owner, repo, err := parseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	check, rels, err := up.fetchAndCheck(owner, repo, current)
+	if err != nil {
+		return nil, err
 	}
-	if current.Equals(rel.Version) {
+
+	skipped := &UpdateResult{Release: &Release{Version: current}, Status: check.Status, Decision: check.Decision, Pending: check.Pending, Reason: check.Reason}
+	switch check.Status {
+	case NoRelease:
+		log.Println("No release detected. Current version is considered up-to-date")
+		return skipped, nil
+	case UpToDate:
 		log.Println("Current version", current, "is the latest. Update is not needed")
-		return rel, nil
+		skipped.Release = check.Release
+		return skipped, nil
+	case Ahead:
+		log.Println("Current version", current, "is newer than the latest release", check.Release.Version, "so update is not needed")
+		return skipped, nil
+	case Blocked:
+		log.Println("Update is not allowed by policy:", check.Decision.Reason)
+		return skipped, nil
+	case Disabled, Pinned:
+		log.Println("Update was skipped because", check.Reason)
+		return skipped, nil
+	}
+
+	rel := check.Release
+	if rel.IsCritical(current) {
+		log.Println("Current version", current, "is no longer supported. Update to", rel.Version, "is critical")
+	}
+	if up.versionYanked(rels, current) {
+		log.Println("Current version", current, "was yanked. Will move to version", rel.Version)
 	}
 	log.Println("Will update", cmdPath, "to the latest version", rel.Version)
 	if err := up.UpdateTo(rel, cmdPath); err != nil {
 		return nil, err
 	}
-	return rel, nil
+	return &UpdateResult{Release: rel, Status: Updated, Decision: check.Decision, Pending: check.Pending, Changes: check.Changes, Reason: check.Reason}, nil
 }
 
 // UpdateSelf updates the running executable itself to the latest version.
 // 'slug' represents 'owner/name' repository on GitHub and 'current' means the current version.
-func (up *Updater) UpdateSelf(current semver.Version, slug string) (*Release, error) {
+func (up *Updater) UpdateSelf(current semver.Version, slug string) (*UpdateResult, error) {
 	cmdPath, err := os.Executable()
 	if err != nil {
 		return nil, err
@@ -154,28 +480,112 @@ func (up *Updater) UpdateSelf(current semver.Version, slug string) (*Release, er
 	return up.UpdateCommand(cmdPath, current, slug)
 }
 
-// UpdateTo downloads an executable from assetURL and replace the current binary with the downloaded one.
-// This function is low-level API to update the binary. Because it does not use GitHub API and downloads asset directly from the URL via HTTP,
-// this function is not available to update a release for private repositories.
+// UpdateToURL downloads an executable from assetURL and replace the current binary with the downloaded one.
+// This method is low-level API to update the binary. Because it does not use GitHub API and downloads asset directly from the URL via HTTP,
+// this method is not available to update a release for private repositories nor a draft release.
+// Progress is reported to Config.Progress with Content-Length of the response as the total size.
 // cmdPath is a file path to command executable.
-func UpdateTo(assetURL, cmdPath string) error {
-	up := DefaultUpdater()
-	src, err := up.downloadDirectlyFromURL(assetURL)
+func (up *Updater) UpdateToURL(assetURL, cmdPath string) error {
+	src, size, err := up.downloadDirectlyFromURL(assetURL)
 	if err != nil {
 		return err
 	}
 	defer src.Close()
-	return uncompressAndUpdate(src, assetURL, cmdPath)
+
+	f, cleanup, err := up.downloadToFile(src, size, nil)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return up.uncompressAndUpdate(f, archiveFormat(assetURL), assetURL, cmdPath)
+}
+
+// UpdateFromFile updates the command with the asset file at 'assetPath' on the local filesystem, such as an asset
+// downloaded manually. The archive format is detected from the content of the file, not from its name. When
+// a validator is configured, the asset is validated with the validation file at 'validationPath' before the
+// executable is extracted. The command is replaced with the extracted executable and is rolled back on failure.
+// When no validator is configured, 'validationPath' must be empty.
+func (up *Updater) UpdateFromFile(assetPath, validationPath, cmdPath string) error {
+	cmdPath, err := resolveCommandPath(cmdPath)
+	if err != nil {
+		return err
+	}
+
+	var validationData []byte
+	if up.validator != nil {
+		if validationPath == "" {
+			return fmt.Errorf("Validation file is required to validate asset %s with validator", assetPath)
+		}
+		data, err := ioutil.ReadFile(validationPath)
+		if err != nil {
+			return fmt.Errorf("Failed reading validation file: %s", err)
+		}
+		validationData, err = up.selectValidationData(filepath.Base(assetPath), data)
+		if err != nil {
+			return err
+		}
+	} else if validationPath != "" {
+		return fmt.Errorf("Validation file %s was given but no validator is configured", validationPath)
+	}
+
+	f, err := os.Open(assetPath)
+	if err != nil {
+		return fmt.Errorf("Failed to open asset file: %s", err)
+	}
+	defer f.Close()
+
+	h := up.newHash()
+	if h != nil {
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("Failed reading asset file %s: %s", assetPath, err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("Failed to rewind asset file %s: %s", assetPath, err)
+		}
+	}
+	if err := up.validateAsset(f, h, validationData); err != nil {
+		return err
+	}
+
+	format, err := detectArchiveFormat(f)
+	if err != nil {
+		return fmt.Errorf("Failed to detect format of asset file %s: %s", assetPath, err)
+	}
+	return up.uncompressAndUpdate(f, format, assetPath, cmdPath)
+}
+
+// UpdateTo downloads an executable from assetURL and replace the current binary with the downloaded one.
+// This function is low-level API to update the binary. Because it does not use GitHub API and downloads asset directly from the URL via HTTP,
+// this function is not available to update a release for private repositories nor a draft release.
+// cmdPath is a file path to command executable.
+// This function is a shortcut version of updater.UpdateToURL. To receive progress, please use UpdateToWithProgress.
+func UpdateTo(assetURL, cmdPath string) error {
+	return DefaultUpdater().UpdateToURL(assetURL, cmdPath)
+}
+
+// UpdateToWithProgress is the same as UpdateTo, but it reports progress of downloading, extracting and applying
+// the binary to the 'progress' callback. NewProgressBar provides a ready-made progress bar for terminal.
+func UpdateToWithProgress(assetURL, cmdPath string, progress ProgressFunc) error {
+	up := DefaultUpdater()
+	up.progress = progress
+	return up.UpdateToURL(assetURL, cmdPath)
+}
+
+// UpdateFromFile updates the command with the asset file on the local filesystem.
+// This function is a shortcut version of updater.UpdateFromFile. Since no validator is configured for the
+// default updater, please use the method to validate the asset.
+func UpdateFromFile(assetPath, validationPath, cmdPath string) error {
+	return DefaultUpdater().UpdateFromFile(assetPath, validationPath, cmdPath)
 }
 
 // UpdateCommand updates a given command binary to the latest version.
 // This function is a shortcut version of updater.UpdateCommand.
-func UpdateCommand(cmdPath string, current semver.Version, slug string) (*Release, error) {
+func UpdateCommand(cmdPath string, current semver.Version, slug string) (*UpdateResult, error) {
 	return DefaultUpdater().UpdateCommand(cmdPath, current, slug)
 }
 
 // UpdateSelf updates the running executable itself to the latest version.
 // This function is a shortcut version of updater.UpdateSelf.
-func UpdateSelf(current semver.Version, slug string) (*Release, error) {
+func UpdateSelf(current semver.Version, slug string) (*UpdateResult, error) {
 	return DefaultUpdater().UpdateSelf(current, slug)
 }
@@ -1,6 +1,12 @@
 package selfupdate
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -351,3 +357,256 @@ func TestUpdateFromGitHubPrivateRepo(t *testing.T) {
 		t.Error("Output from test binary after update is unexpected:", out)
 	}
 }
+
+func TestUpdateCommandToDraftRelease(t *testing.T) {
+	draft := binaryRelease("v1.1.0")
+	draft.draft = true
+	srv, mux := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), draft})
+	defer srv.Close()
+
+	// Asset of draft release is only available for authenticated users
+	mux.HandleFunc("/api/v3/repos/owner/repo/releases/assets/200", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("v1.1.0"))
+	})
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	res, err := newTestUpdater(t, srv, Config{}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != UpToDate {
+		t.Errorf("Draft release should be ignored by default but got status %q", res.Status)
+	}
+
+	res, err = newTestUpdater(t, srv, Config{IncludeDrafts: true}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Updated || res.Version.String() != "1.1.0" || !res.Draft {
+		t.Errorf("Command should be updated to draft release: %+v", res)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated to draft release: %q", got)
+	}
+}
+
+// zipRelease returns a test release which has a zip asset containing an executable whose content is the tag
+// and its SHA256 validation asset. When 'hash' is not empty, it is used as the content of the validation asset.
+func zipRelease(t *testing.T, tag, hash string) testRelease {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	w, err := z.Create("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(tag)); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if hash == "" {
+		hash = fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+	}
+	name := platformAsset("foo")
+	return testRelease{
+		tag:    tag,
+		assets: []string{name, name + ".sha256"},
+		files:  map[string][]byte{name: buf.Bytes(), name + ".sha256": []byte(hash + "  " + name + "\n")},
+	}
+}
+
+// bytesValidator is a validator which does not implement HashValidator.
+type bytesValidator struct {
+	release []byte
+}
+
+func (v *bytesValidator) Validate(release, asset []byte) error {
+	v.release = release
+	return (&SHA2Validator{}).Validate(release, asset)
+}
+
+func (v *bytesValidator) Suffix() string {
+	return ".sha256"
+}
+
+func TestUpdateCommandStreamingWithValidator(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	for _, v := range []Validator{&SHA2Validator{}, &bytesValidator{}} {
+		exe, cleanup := newTestExecutable(t, "v1.0.0")
+		defer cleanup()
+
+		res, err := newTestUpdater(t, srv, Config{Validator: v}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+		if err != nil {
+			t.Fatalf("Update with validator %T failed: %s", v, err)
+		}
+		if res.Status != Updated {
+			t.Errorf("Wanted status %q with validator %T but got %q", Updated, v, res.Status)
+		}
+		if got := readTestExecutable(t, exe); got != "v1.1.0" {
+			t.Errorf("Executable was not updated with validator %T: %q", v, got)
+		}
+	}
+
+	// Validator which does not implement HashValidator receives whole content of the asset
+	v := &bytesValidator{}
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+	if _, err := newTestUpdater(t, srv, Config{Validator: v}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v.release, rel.files[platformAsset("foo")]) {
+		t.Error("Validator did not receive whole content of the asset")
+	}
+}
+
+func TestUpdateCommandStreamingValidationFailed(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", strings.Repeat("0", 64))
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	_, err := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}}).UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+	if err == nil {
+		t.Fatal("Error should occur for hash mismatch")
+	}
+	if !strings.Contains(err.Error(), "Failed validating asset content") {
+		t.Error("Unexpected error:", err)
+	}
+	if got := readTestExecutable(t, exe); got != "v1.0.0" {
+		t.Errorf("Executable should not be updated when validation failed: %q", got)
+	}
+}
+
+func TestUpdateFromFile(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	asset := platformAsset("foo")
+
+	dir, cleanup := newTestCacheDir(t)
+	defer cleanup()
+	// The name of the file does not tell its format
+	assetPath := filepath.Join(dir, "downloaded")
+	if err := ioutil.WriteFile(assetPath, rel.files[asset], 0644); err != nil {
+		t.Fatal(err)
+	}
+	validationPath := filepath.Join(dir, "downloaded.sha256")
+	if err := ioutil.WriteFile(validationPath, rel.files[asset+".sha256"], 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The extension of the file name is misleading
+	misleadingPath := filepath.Join(dir, "downloaded.tar.gz")
+	if err := ioutil.WriteFile(misleadingPath, rel.files[asset], 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.sha256")
+	if err := ioutil.WriteFile(invalidPath, []byte(strings.Repeat("0", 64)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		what       string
+		asset      string
+		validator  Validator
+		validation string
+		err        string
+	}{
+		{"validated", assetPath, &SHA2Validator{}, validationPath, ""},
+		{"without validator", assetPath, nil, "", ""},
+		{"misleading extension", misleadingPath, nil, "", ""},
+		{"invalid asset", assetPath, &SHA2Validator{}, invalidPath, "Failed validating asset content"},
+		{"validation file is missing", assetPath, &SHA2Validator{}, "", "Validation file is required"},
+		{"validator is missing", assetPath, nil, validationPath, "no validator is configured"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			exe, cleanupExe := newTestExecutable(t, "v1.0.0")
+			defer cleanupExe()
+
+			up, err := NewUpdater(Config{Validator: tc.validator})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = up.UpdateFromFile(tc.asset, tc.validation, exe)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := readTestExecutable(t, exe); got != "v1.1.0" {
+					t.Errorf("Executable was not updated from file: %q", got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Error should occur")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Error should contain %q but got %q", tc.err, err.Error())
+			}
+			if got := readTestExecutable(t, exe); got != "v1.0.0" {
+				t.Errorf("Executable should not be updated: %q", got)
+			}
+		})
+	}
+}
+
+// checksumsRelease converts the release created by zipRelease into a release whose checksum is listed in
+// checksums.txt with checksums of other assets.
+func checksumsRelease(t *testing.T, tag, hash string) testRelease {
+	rel := zipRelease(t, tag, hash)
+	name := platformAsset("foo")
+	sums := strings.Repeat("1", 64) + "  foo_other_arch.zip\n" + string(rel.files[name+".sha256"])
+	rel.assets = []string{name, "checksums.txt"}
+	rel.files = map[string][]byte{name: rel.files[name], "checksums.txt": []byte(sums)}
+	return rel
+}
+
+func TestUpdateCommandWithChecksumsFile(t *testing.T) {
+	for _, tc := range []struct {
+		what string
+		hash string
+		err  string
+	}{
+		{"valid", "", ""},
+		{"hash mismatch", strings.Repeat("0", 64), "hash mismatch"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), checksumsRelease(t, "v1.1.0", tc.hash)})
+			defer srv.Close()
+
+			exe, cleanup := newTestExecutable(t, "v1.0.0")
+			defer cleanup()
+
+			up := newTestUpdater(t, srv, Config{Validator: &ChecksumsValidator{}})
+			_, err := up.UpdateCommand(exe, semver.MustParse("1.0.0"), "owner/repo")
+			if tc.err != "" {
+				if err == nil {
+					t.Fatal("Error should occur")
+				}
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Error should contain %q but got %q", tc.err, err.Error())
+				}
+				if got := readTestExecutable(t, exe); got != "v1.0.0" {
+					t.Errorf("Executable should not be updated: %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestExecutable(t, exe); got != "v1.1.0" {
+				t.Errorf("Executable was not updated: %q", got)
+			}
+		})
+	}
+}
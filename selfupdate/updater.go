@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"time"
 
+	"github.com/blang/semver"
 	"github.com/google/go-github/v30/github"
 	gitconfig "github.com/tcnksm/go-gitconfig"
 	"golang.org/x/oauth2"
@@ -15,10 +17,29 @@ import (
 // Updater is responsible for managing the context of self-update.
 // It contains GitHub client and its context.
 type Updater struct {
-	api       *github.Client
-	apiCtx    context.Context
-	validator Validator
-	filters   []*regexp.Regexp
+	api                *github.Client
+	apiCtx             context.Context
+	client             *http.Client
+	validator          Validator
+	filters            []*regexp.Regexp
+	yanked             []semver.Version
+	policy             UpdatePolicy
+	drafts             bool
+	progress           ProgressFunc
+	cacheDir           string
+	cache              *assetCache
+	stagingDirectory   string
+	maxDownloadBytes   int64
+	maxExecutableBytes int64
+
+	mirrors        []string
+	mirrorTemplate string
+
+	minReleaseAge time.Duration
+	toolName      string
+	controlFile   string
+
+	machineID string
 }
 
 // Config represents the configuration of self-update.
@@ -37,6 +58,73 @@ type Config struct {
 	// An asset is selected if it matches any of those, in addition to the regular tag, os, arch, extensions.
 	// Please make sure that your filter(s) uniquely match an asset.
 	Filters []string
+	// YankedVersions is a deny-list of versions which were withdrawn. Yanked releases are never detected as
+	// the latest and UpdateCommand moves users off a yanked version even if the latest release is older.
+	// A release can also be yanked by writing 'yanked: true' in a metadata comment in its release notes
+	// or by uploading an asset named 'YANKED' to it.
+	//
+	//   <!-- selfupdate
+	//   yanked: true
+	//   -->
+	YankedVersions []string
+	// Policy restricts which release UpdateCommand and UpdateSelf update the current version to. By default,
+	// updating to a release older than the current version is not allowed.
+	Policy UpdatePolicy
+	// MachineID is a stable ID of this machine used for staged rollouts. When a release is rolled out to a
+	// part of machines, whether this machine is eligible is decided by hashing this ID with the version.
+	// When it is empty, the machine ID of the system (e.g. /etc/machine-id) is used. If it is not available,
+	// a random ID is generated and persisted in the user config directory.
+	MachineID string
+	// MinReleaseAge is the minimum age of releases to be detected. Releases published more recently than this
+	// duration are ignored until they have soaked for the duration. The release which would be the latest
+	// without this option is reported as pending in the result of Check and UpdateCommand.
+	MinReleaseAge time.Duration
+	// ToolName is a name of the tool used for environment variables to control self-update. Users can disable
+	// self-update by setting $<TOOL>_SELFUPDATE=off and pin the version by $<TOOL>_VERSION_PIN=1.2.3. <TOOL>
+	// is the tool name in upper case whose non-alphanumeric characters are replaced with '_'. When it is empty,
+	// the repository name is used.
+	ToolName string
+	// IncludeDrafts makes draft releases eligible for detection and update as if they were published. This is
+	// useful to test the update path against a draft before publishing it. Since draft releases are only visible
+	// to authenticated users who have push access to the repository, an API token is required.
+	IncludeDrafts bool
+	// Progress is called to report progress of downloading, validating, extracting and applying a release
+	// while updating a command. NewProgressBar provides a ready-made progress bar for terminal.
+	Progress ProgressFunc
+	// CacheDir is a directory to persist partially downloaded assets. When a download is interrupted, the next
+	// attempt resumes it from where it stopped with HTTP Range requests. Empty means downloads are not resumable.
+	CacheDir string
+	// SharedCacheDir is a directory of the cache of downloaded assets shared by tools and processes. UpdateTo
+	// looks up the asset and its validation file in the cache before downloading them and stores them after
+	// downloading. A cached asset is verified with its SHA-256 digest and validated with Validator, then
+	// the network is not accessed at all. Empty means no cache is used.
+	SharedCacheDir string
+	// SharedCacheMaxBytes is the maximum total size of assets in the shared cache. Least recently used assets
+	// are evicted when the total size exceeds it. Zero means 1GiB and a negative value means no limit.
+	SharedCacheMaxBytes int64
+	// StagingDir is a directory to stage executables by Stage and StageCommand until they are applied by
+	// ApplyStaged and ApplyStagedCommand. It should be writable only by the user. Empty means a directory in
+	// the user cache directory.
+	StagingDir string
+	// MaxDownloadBytes is the maximum size of an asset to download in bytes. When the size of the asset or the
+	// number of downloaded bytes exceeds it, the update fails with *SizeLimitError. Zero means no limit.
+	MaxDownloadBytes int64
+	// MaxExecutableBytes is the maximum size of an executable extracted from an asset in bytes. It prevents
+	// a compression bomb from exhausting memory and disk. When the extracted executable exceeds it, the update
+	// fails with *SizeLimitError. Zero means no limit.
+	MaxExecutableBytes int64
+	// Mirrors are base URLs of mirrors of release assets such as an internal Artifactory or a CDN. They are tried
+	// in order before downloading an asset from GitHub. Since an asset from a mirror is always validated against
+	// the validation file downloaded from GitHub, Validator is required to use mirrors.
+	Mirrors []string
+	// MirrorTemplate is a template of asset URLs on mirrors. {mirror}, {owner}, {repo}, {tag}, {version} and
+	// {asset} are replaced with the mirror base URL, the repository owner, the repository name, the tag name,
+	// the version and the asset name. When it is empty, "{mirror}/{owner}/{repo}/{tag}/{asset}" is used.
+	MirrorTemplate string
+	// ControlFile is a path to an optional file to control self-update. Each line of the file is 'key = value'.
+	// 'selfupdate = off' disables self-update and 'version_pin = 1.2.3' pins the version. Environment variables
+	// take precedence over the file. Nothing happens when the file does not exist.
+	ControlFile string
 }
 
 func newHTTPClient(ctx context.Context, token string) *http.Client {
@@ -60,6 +148,35 @@ func NewUpdater(config Config) (*Updater, error) {
 	ctx := context.Background()
 	hc := newHTTPClient(ctx, token)
 
+	if config.IncludeDrafts && token == "" {
+		return nil, fmt.Errorf("API token is required to include draft releases because they are only visible to authenticated users")
+	}
+
+	if len(config.Mirrors) > 0 && config.Validator == nil {
+		return nil, fmt.Errorf("Validator is required to download assets from mirrors because their integrity must be checked against GitHub")
+	}
+
+	switch v := config.Validator.(type) {
+	case *HashSumValidator:
+		if v.Hash != nil && v.ValidationSuffix == "" {
+			return nil, fmt.Errorf("ValidationSuffix of HashSumValidator is required when its Hash is set since the suffix of the hash sum file cannot be derived from the custom hash")
+		}
+		if v.Hash == nil {
+			if _, err := v.Algorithm.New(); err != nil {
+				return nil, fmt.Errorf("Invalid Algorithm of HashSumValidator: %v", err)
+			}
+		}
+	case *ChecksumsValidator:
+		if _, err := v.Algorithm.New(); err != nil {
+			return nil, fmt.Errorf("Invalid Algorithm of ChecksumsValidator: %v", err)
+		}
+	}
+
+	var cache *assetCache
+	if config.SharedCacheDir != "" {
+		cache = newAssetCache(config.SharedCacheDir, config.SharedCacheMaxBytes)
+	}
+
 	filtersRe := make([]*regexp.Regexp, 0, len(config.Filters))
 	for _, filter := range config.Filters {
 		re, err := regexp.Compile(filter)
@@ -69,20 +186,51 @@ func NewUpdater(config Config) (*Updater, error) {
 		filtersRe = append(filtersRe, re)
 	}
 
-	if config.EnterpriseBaseURL == "" {
-		client := github.NewClient(hc)
-		return &Updater{api: client, apiCtx: ctx, validator: config.Validator, filters: filtersRe}, nil
+	yanked := make([]semver.Version, 0, len(config.YankedVersions))
+	for _, v := range config.YankedVersions {
+		ver, err := semver.ParseTolerant(v)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse yanked version %q: %v", v, err)
+		}
+		yanked = append(yanked, ver)
 	}
 
-	u := config.EnterpriseUploadURL
-	if u == "" {
-		u = config.EnterpriseBaseURL
+	up := &Updater{
+		api:                github.NewClient(hc),
+		apiCtx:             ctx,
+		client:             hc,
+		validator:          config.Validator,
+		filters:            filtersRe,
+		yanked:             yanked,
+		policy:             config.Policy,
+		drafts:             config.IncludeDrafts,
+		progress:           config.Progress,
+		cacheDir:           config.CacheDir,
+		cache:              cache,
+		stagingDirectory:   config.StagingDir,
+		maxDownloadBytes:   config.MaxDownloadBytes,
+		maxExecutableBytes: config.MaxExecutableBytes,
+		mirrors:            config.Mirrors,
+		mirrorTemplate:     config.MirrorTemplate,
+		minReleaseAge:      config.MinReleaseAge,
+		toolName:           config.ToolName,
+		controlFile:        config.ControlFile,
+		machineID:          config.MachineID,
 	}
-	client, err := github.NewEnterpriseClient(config.EnterpriseBaseURL, u, hc)
-	if err != nil {
-		return nil, err
+
+	if config.EnterpriseBaseURL != "" {
+		u := config.EnterpriseUploadURL
+		if u == "" {
+			u = config.EnterpriseBaseURL
+		}
+		client, err := github.NewEnterpriseClient(config.EnterpriseBaseURL, u, hc)
+		if err != nil {
+			return nil, err
+		}
+		up.api = client
 	}
-	return &Updater{api: client, apiCtx: ctx, validator: config.Validator, filters: filtersRe}, nil
+
+	return up, nil
 }
 
 // DefaultUpdater creates a new updater instance with default configuration.
@@ -95,5 +243,12 @@ func DefaultUpdater() *Updater {
 	}
 	ctx := context.Background()
 	client := newHTTPClient(ctx, token)
-	return &Updater{api: github.NewClient(client), apiCtx: ctx}
+	return &Updater{api: github.NewClient(client), apiCtx: ctx, client: client}
+}
+
+// withContext returns a copy of the updater which sends API requests and downloads assets with the context.
+func (up *Updater) withContext(ctx context.Context) *Updater {
+	c := *up
+	c.apiCtx = ctx
+	return &c
 }
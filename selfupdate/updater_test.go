@@ -1,6 +1,7 @@
 package selfupdate
 
 import (
+	"crypto/sha512"
 	"os"
 	"strings"
 	"testing"
@@ -104,3 +105,56 @@ func TestFilterRegexIsBroken(t *testing.T) {
 		t.Fatalf("Error message is unexpected: %q", msg)
 	}
 }
+
+func TestIncludeDraftsRequiresToken(t *testing.T) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token != "" {
+		defer os.Setenv("GITHUB_TOKEN", token)
+	}
+	os.Setenv("GITHUB_TOKEN", "")
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", "testdata") // Do not read API token from user's gitconfig
+
+	_, err := NewUpdater(Config{IncludeDrafts: true})
+	if err == nil {
+		t.Fatal("Error should occur when drafts are included without API token")
+	}
+	if !strings.Contains(err.Error(), "API token is required") {
+		t.Error("Unexpected error:", err)
+	}
+
+	if _, err := NewUpdater(Config{APIToken: "hogehoge", IncludeDrafts: true}); err != nil {
+		t.Error("Drafts should be included with API token:", err)
+	}
+}
+
+func TestCustomHashRequiresValidationSuffix(t *testing.T) {
+	_, err := NewUpdater(Config{Validator: &HashSumValidator{Hash: sha512.New}})
+	if err == nil {
+		t.Fatal("Error should occur when custom hash is set without validation suffix")
+	}
+	if !strings.Contains(err.Error(), "ValidationSuffix of HashSumValidator is required") {
+		t.Error("Unexpected error:", err)
+	}
+
+	if _, err := NewUpdater(Config{Validator: &HashSumValidator{Hash: sha512.New, ValidationSuffix: ".sha512"}}); err != nil {
+		t.Error("Custom hash with validation suffix should be accepted:", err)
+	}
+}
+
+func TestUnknownHashAlgorithmIsRejected(t *testing.T) {
+	for _, v := range []Validator{
+		&HashSumValidator{Algorithm: HashAlgorithm(9)},
+		&ChecksumsValidator{Algorithm: HashAlgorithm(9)},
+	} {
+		_, err := NewUpdater(Config{Validator: v})
+		if err == nil {
+			t.Errorf("Error should occur for unknown hash algorithm of %T", v)
+			continue
+		}
+		if !strings.Contains(err.Error(), "Unknown hash algorithm: 9") {
+			t.Error("Unexpected error:", err)
+		}
+	}
+}
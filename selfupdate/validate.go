@@ -1,11 +1,19 @@
 package selfupdate
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/asn1"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"math/big"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 // Validator represents an interface which enables additional validation of releases.
@@ -18,6 +26,33 @@ type Validator interface {
 	Suffix() string
 }
 
+// HashValidator is a Validator which can validate a release only with its digest. When the validator
+// implements this interface, the digest is calculated incrementally while downloading the release so that
+// the whole release is not kept in memory. All built-in validators implement this interface.
+type HashValidator interface {
+	Validator
+	// NewHash returns a new hash to calculate the digest of release.
+	NewHash() hash.Hash
+	// ValidateHash validates the digest of release calculated by the hash returned from NewHash against
+	// an additional asset bytes.
+	ValidateHash(digest, asset []byte) error
+}
+
+// ValidationAssetSelector is a Validator whose validation asset is not named as the asset name with Suffix(),
+// such as a checksums file shared by all assets of a release. When the validator implements this interface,
+// the validation asset is looked up with MatchValidationAsset and the validation data for the asset is
+// selected from its content with SelectValidationData before validating. ChecksumsValidator implements this
+// interface.
+type ValidationAssetSelector interface {
+	Validator
+	// MatchValidationAsset returns true when 'name' is the name of the validation asset for the asset named
+	// 'asset'.
+	MatchValidationAsset(asset, name string) bool
+	// SelectValidationData returns the validation data for the asset named 'asset' from the content of the
+	// validation asset. The returned data is passed to Validate or ValidateHash.
+	SelectValidationData(asset string, data []byte) ([]byte, error)
+}
+
 // SHA2Validator specifies a SHA256 validator for additional file validation
 // before updating.
 type SHA2Validator struct {
@@ -26,12 +61,18 @@ type SHA2Validator struct {
 // Validate validates the SHA256 sum of the release against the contents of an
 // additional asset file.
 func (v *SHA2Validator) Validate(release, asset []byte) error {
-	calculatedHash := fmt.Sprintf("%x", sha256.Sum256(release))
-	hash := fmt.Sprintf("%s", asset[:sha256.BlockSize])
-	if calculatedHash != hash {
-		return fmt.Errorf("sha2: validation failed: hash mismatch: expected=%q, got=%q", calculatedHash, hash)
-	}
-	return nil
+	digest := sha256.Sum256(release)
+	return v.ValidateHash(digest[:], asset)
+}
+
+// NewHash returns a new SHA256 hash.
+func (v *SHA2Validator) NewHash() hash.Hash {
+	return sha256.New()
+}
+
+// ValidateHash validates the SHA256 digest of the release against the contents of an additional asset file.
+func (v *SHA2Validator) ValidateHash(digest, asset []byte) error {
+	return (&HashSumValidator{}).ValidateHash(digest, asset)
 }
 
 // Suffix returns the suffix for SHA2 validation.
@@ -39,6 +80,159 @@ func (v *SHA2Validator) Suffix() string {
 	return ".sha256"
 }
 
+// HashAlgorithm is a hash algorithm used by HashSumValidator and ChecksumsValidator.
+type HashAlgorithm int
+
+const (
+	// HashSHA256 is SHA-256. Its validation file has the suffix '.sha256'.
+	HashSHA256 HashAlgorithm = iota
+	// HashSHA384 is SHA-384. Its validation file has the suffix '.sha384'.
+	HashSHA384
+	// HashSHA512 is SHA-512. Its validation file has the suffix '.sha512'.
+	HashSHA512
+	// HashBLAKE2b512 is BLAKE2b-512 calculated by b2sum command. Its validation file has the suffix '.b2'.
+	HashBLAKE2b512
+)
+
+// String returns the name of the algorithm used in the BSD format of checksum files.
+func (a HashAlgorithm) String() string {
+	switch a {
+	case HashSHA256:
+		return "SHA256"
+	case HashSHA384:
+		return "SHA384"
+	case HashSHA512:
+		return "SHA512"
+	case HashBLAKE2b512:
+		return "BLAKE2b"
+	default:
+		return fmt.Sprintf("HashAlgorithm(%d)", int(a))
+	}
+}
+
+// New returns a new hash of the algorithm. It returns an error when the algorithm is unknown.
+func (a HashAlgorithm) New() (hash.Hash, error) {
+	switch a {
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA384:
+		return sha512.New384(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE2b512:
+		return blake2b.New512(nil) // Never fails without key
+	default:
+		return nil, fmt.Errorf("Unknown hash algorithm: %d", int(a))
+	}
+}
+
+func (a HashAlgorithm) suffix() string {
+	if a == HashBLAKE2b512 {
+		return ".b2"
+	}
+	return "." + strings.ToLower(a.String())
+}
+
+// HashSumValidator specifies a validator with a hash sum file for additional file validation before updating.
+// The hash algorithm and the suffix of the hash sum file are configurable. The hash sum file may contain only
+// the hash in hex, the output of sha256sum-like commands ('{hash}  {file name}') or the BSD format
+// ('SHA512 ({file name}) = {hash}'). Empty lines and comments are ignored. A file listing hashes of multiple
+// assets is rejected since the hash of the asset cannot be selected. Please use ChecksumsValidator for it.
+type HashSumValidator struct {
+	// Algorithm is the hash algorithm. Zero value means SHA-256. NewUpdater returns an error when it is
+	// unknown.
+	Algorithm HashAlgorithm
+	// Hash is a function to create a hash for an algorithm which is not built in such as BLAKE3. When it is
+	// set, Algorithm is ignored and ValidationSuffix must be set. Otherwise NewUpdater returns an error.
+	Hash func() hash.Hash
+	// ValidationSuffix is the suffix of the hash sum file. Empty means the suffix for Algorithm such as
+	// '.sha512'.
+	ValidationSuffix string
+}
+
+func (v *HashSumValidator) name() string {
+	if v.Hash != nil {
+		return "hash"
+	}
+	return strings.ToLower(v.Algorithm.String())
+}
+
+// Validate validates the hash sum of the release against the contents of an additional asset file.
+func (v *HashSumValidator) Validate(release, asset []byte) error {
+	h, err := v.newHash()
+	if err != nil {
+		return fmt.Errorf("%s: validation failed: %v", v.name(), err)
+	}
+	h.Write(release)
+	return v.ValidateHash(h.Sum(nil), asset)
+}
+
+// NewHash returns a new hash of the algorithm. It returns nil when the algorithm is unknown.
+func (v *HashSumValidator) NewHash() hash.Hash {
+	h, err := v.newHash()
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+func (v *HashSumValidator) newHash() (hash.Hash, error) {
+	if v.Hash != nil {
+		return v.Hash(), nil
+	}
+	return v.Algorithm.New()
+}
+
+// ValidateHash validates the digest of the release against the contents of an additional asset file.
+func (v *HashSumValidator) ValidateHash(digest, asset []byte) error {
+	var algo, sum string
+	found := 0
+	for _, line := range strings.Split(string(asset), "\n") {
+		a, s, _, ok := parseChecksumLine(line)
+		if !ok {
+			continue
+		}
+		algo, sum = a, s
+		found++
+	}
+	if found == 0 {
+		return fmt.Errorf("%s: validation failed: hash was not found in hash file: %q", v.name(), asset)
+	}
+	if found > 1 {
+		return fmt.Errorf("%s: validation failed: hash file lists %d hashes. Please use ChecksumsValidator for a file listing hashes of multiple assets", v.name(), found)
+	}
+	if algo != "" && v.Hash == nil && !strings.EqualFold(algo, v.Algorithm.String()) {
+		return fmt.Errorf("%s: validation failed: hash file is for %s", v.name(), algo)
+	}
+	return validateHexHash(v.name(), digest, sum)
+}
+
+// Suffix returns the suffix of the hash sum file.
+func (v *HashSumValidator) Suffix() string {
+	if v.ValidationSuffix != "" {
+		return v.ValidationSuffix
+	}
+	return v.Algorithm.suffix()
+}
+
+// validateHexHash compares the digest with the hash in hex. 'name' is a prefix of error messages.
+func validateHexHash(name string, digest []byte, sum string) error {
+	want, err := hex.DecodeString(sum)
+	if err != nil {
+		return fmt.Errorf("%s: validation failed: invalid hash %q: %v", name, sum, err)
+	}
+	if len(want) < len(digest) {
+		return fmt.Errorf("%s: validation failed: hash is too short: %q", name, sum)
+	}
+	if len(want) != len(digest) {
+		return fmt.Errorf("%s: validation failed: hash is too long: %q", name, sum)
+	}
+	if !bytes.Equal(digest, want) {
+		return fmt.Errorf("%s: validation failed: hash mismatch: expected=%x, got=%x", name, want, digest)
+	}
+	return nil
+}
+
 // ECDSAValidator specifies a ECDSA validator for additional file validation
 // before updating.
 type ECDSAValidator struct {
@@ -49,9 +243,18 @@ type ECDSAValidator struct {
 // contained in an additional asset file.
 // additional asset file.
 func (v *ECDSAValidator) Validate(input, signature []byte) error {
-	h := sha256.New()
-	h.Write(input)
+	digest := sha256.Sum256(input)
+	return v.ValidateHash(digest[:], signature)
+}
 
+// NewHash returns a new SHA256 hash.
+func (v *ECDSAValidator) NewHash() hash.Hash {
+	return sha256.New()
+}
+
+// ValidateHash validates the ECDSA signature of the SHA256 digest of the release against the signature
+// contained in an additional asset file.
+func (v *ECDSAValidator) ValidateHash(digest, signature []byte) error {
 	var rs struct {
 		R *big.Int
 		S *big.Int
@@ -60,7 +263,7 @@ func (v *ECDSAValidator) Validate(input, signature []byte) error {
 		return fmt.Errorf("failed to unmarshal ecdsa signature: %v", err)
 	}
 
-	if !ecdsa.Verify(v.PublicKey, h.Sum([]byte{}), rs.R, rs.S) {
+	if !ecdsa.Verify(v.PublicKey, digest, rs.R, rs.S) {
 		return fmt.Errorf("ecdsa: signature verification failed")
 	}
 
@@ -71,3 +274,96 @@ func (v *ECDSAValidator) Validate(input, signature []byte) error {
 func (v *ECDSAValidator) Suffix() string {
 	return ".sig"
 }
+
+// ChecksumsValidator specifies a validator with a checksums file which lists checksums of all assets of a
+// release, such as 'checksums.txt' generated by goreleaser or 'SHA256SUMS'. Both the format of sha256sum
+// ('{hash}  {file name}') and the BSD format ('SHA256 ({file name}) = {hash}') are supported. The line for the
+// asset is selected by its file name.
+type ChecksumsValidator struct {
+	// Pattern is the file name of the checksums file or its pattern matched with path.Match such as
+	// '*_checksums.txt'. Empty means 'checksums.txt'.
+	Pattern string
+	// Algorithm is the hash algorithm of checksums. Zero value means SHA-256. NewUpdater returns an error when
+	// it is unknown.
+	Algorithm HashAlgorithm
+}
+
+// MatchValidationAsset returns true when the name matches to the pattern of the checksums file.
+func (v *ChecksumsValidator) MatchValidationAsset(asset, name string) bool {
+	pat := v.Pattern
+	if pat == "" {
+		pat = "checksums.txt"
+	}
+	ok, err := path.Match(pat, name)
+	return err == nil && ok
+}
+
+// parseChecksumLine parses a line of checksums file and returns the name of the hash algorithm, the hash and
+// the file name. The algorithm is empty unless the line is in the BSD format and the file name is empty when
+// the line only contains the hash.
+func parseChecksumLine(line string) (string, string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", "", false
+	}
+
+	// BSD format: 'SHA256 (foo.zip) = 0123...'
+	if i := strings.Index(line, " ("); i > 0 {
+		if j := strings.LastIndex(line, ") = "); j > i {
+			return line[:i], strings.TrimSpace(line[j+4:]), line[i+2 : j], true
+		}
+	}
+
+	// sha256sum format: '0123...  foo.zip' or '0123... *foo.zip' for binary mode
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return "", line, "", true
+	}
+	name := strings.TrimPrefix(strings.TrimLeft(line[i:], " \t"), "*")
+	return "", line[:i], name, true
+}
+
+// SelectValidationData returns the hash of the asset in the checksums file.
+func (v *ChecksumsValidator) SelectValidationData(asset string, data []byte) ([]byte, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		algo, sum, name, ok := parseChecksumLine(line)
+		if !ok || name == "" || path.Base(name) != asset {
+			continue
+		}
+		if algo != "" && !strings.EqualFold(algo, v.Algorithm.String()) {
+			return nil, fmt.Errorf("checksums: unsupported hash algorithm %q for %q", algo, asset)
+		}
+		return []byte(sum), nil
+	}
+	return nil, fmt.Errorf("checksums: checksum of %q was not found in checksums file", asset)
+}
+
+// Validate validates the hash sum of the release against the hash selected from the checksums file.
+func (v *ChecksumsValidator) Validate(release, sum []byte) error {
+	h, err := v.Algorithm.New()
+	if err != nil {
+		return fmt.Errorf("checksums: validation failed: %v", err)
+	}
+	h.Write(release)
+	return v.ValidateHash(h.Sum(nil), sum)
+}
+
+// NewHash returns a new hash of the algorithm. It returns nil when the algorithm is unknown.
+func (v *ChecksumsValidator) NewHash() hash.Hash {
+	h, err := v.Algorithm.New()
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// ValidateHash validates the digest of the release against the hash selected from the checksums file.
+func (v *ChecksumsValidator) ValidateHash(digest, sum []byte) error {
+	return validateHexHash("checksums", digest, strings.TrimSpace(string(sum)))
+}
+
+// Suffix returns the suffix of the validation file. It is only used to name the validation file in a bundle
+// since the checksums file is found with Pattern.
+func (v *ChecksumsValidator) Suffix() string {
+	return ".checksums"
+}
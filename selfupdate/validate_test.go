@@ -2,10 +2,17 @@ package selfupdate
 
 import (
 	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
+	"hash"
 	"io/ioutil"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestSHA2Validator(t *testing.T) {
@@ -126,6 +133,26 @@ func TestValidatorSuffix(t *testing.T) {
 			v:      &ECDSAValidator{},
 			suffix: ".sig",
 		},
+		{
+			v:      &HashSumValidator{},
+			suffix: ".sha256",
+		},
+		{
+			v:      &HashSumValidator{Algorithm: HashSHA384},
+			suffix: ".sha384",
+		},
+		{
+			v:      &HashSumValidator{Algorithm: HashSHA512},
+			suffix: ".sha512",
+		},
+		{
+			v:      &HashSumValidator{Algorithm: HashBLAKE2b512},
+			suffix: ".b2",
+		},
+		{
+			v:      &HashSumValidator{Algorithm: HashSHA512, ValidationSuffix: ".sha512sum"},
+			suffix: ".sha512sum",
+		},
 	} {
 		want := test.suffix
 		got := test.v.Suffix()
@@ -134,3 +161,276 @@ func TestValidatorSuffix(t *testing.T) {
 		}
 	}
 }
+
+func TestHashValidator(t *testing.T) {
+	pemData, err := ioutil.ReadFile("testdata/Test.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		t.Fatal("failed to decode PEM block")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal("failed to parse certificate")
+	}
+
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		v     HashValidator
+		asset string
+	}{
+		{&SHA2Validator{}, "testdata/foo.zip.sha256"},
+		{&HashSumValidator{}, "testdata/foo.zip.sha256"},
+		{&ECDSAValidator{PublicKey: cert.PublicKey.(*ecdsa.PublicKey)}, "testdata/foo.zip.sig"},
+	} {
+		asset, err := ioutil.ReadFile(tc.asset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := tc.v.NewHash()
+		h.Write(data[:100])
+		h.Write(data[100:])
+		if err := tc.v.ValidateHash(h.Sum(nil), asset); err != nil {
+			t.Errorf("Validation of %T failed: %s", tc.v, err)
+		}
+
+		h = tc.v.NewHash()
+		h.Write(data[1:])
+		if err := tc.v.ValidateHash(h.Sum(nil), asset); err == nil {
+			t.Errorf("Validation of %T should fail for broken content", tc.v)
+		}
+	}
+}
+
+func TestSHA2ValidatorTooShortHash(t *testing.T) {
+	err := (&SHA2Validator{}).Validate([]byte("foo"), []byte("abcdef"))
+	if err == nil {
+		t.Fatal("Error should occur for too short hash")
+	}
+	if !strings.Contains(err.Error(), "too short") {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestChecksumsValidatorMatchValidationAsset(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"", "checksums.txt", true},
+		{"", "foo_checksums.txt", false},
+		{"SHA256SUMS", "SHA256SUMS", true},
+		{"*_checksums.txt", "foo_1.2.3_checksums.txt", true},
+		{"*_checksums.txt", "checksums.txt", false},
+		{"[", "[", false},
+	} {
+		v := &ChecksumsValidator{Pattern: tc.pattern}
+		if got := v.MatchValidationAsset("foo.zip", tc.name); got != tc.want {
+			t.Errorf("Pattern %q for %q should be %v but got %v", tc.pattern, tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestChecksumsValidatorSelectValidationData(t *testing.T) {
+	const (
+		fooHash = "5d41402abc4b2a76b9719d911017c592bb6b7c5ec0e0d1b9e8a8f8e0a1c1c1c1"
+		barHash = "8843d7f92416211de9ebb963ff4ce28125932878b7c5ec0e0d1b9e8a8f8e0a1c"
+	)
+	v := &ChecksumsValidator{}
+	for _, tc := range []struct {
+		what string
+		data string
+		want string
+		err  string
+	}{
+		{"sha256sum", barHash + "  bar.zip\n" + fooHash + "  foo.zip\n", fooHash, ""},
+		{"binary mode", fooHash + " *foo.zip\n", fooHash, ""},
+		{"BSD", "SHA256 (bar.zip) = " + barHash + "\nSHA256 (foo.zip) = " + fooHash + "\n", fooHash, ""},
+		{"directory", fooHash + "  dist/foo.zip\n", fooHash, ""},
+		{"CRLF", "# comment\r\n\r\n" + fooHash + "  foo.zip\r\n", fooHash, ""},
+		{"not found", barHash + "  bar.zip\n" + fooHash + "  foo.zip.sig\n", "", "was not found"},
+		{"other algorithm", "MD5 (foo.zip) = d41d8cd98f00b204e9800998ecf8427e\n", "", "unsupported hash algorithm"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			got, err := v.SelectValidationData("foo.zip", []byte(tc.data))
+			if tc.err != "" {
+				if err == nil {
+					t.Fatal("Error should occur")
+				}
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Error should contain %q but got %q", tc.err, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Wanted %q but got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestChecksumsValidator(t *testing.T) {
+	v := &ChecksumsValidator{}
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashData, err := ioutil.ReadFile("testdata/foo.zip.sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := v.SelectValidationData("foo.zip", hashData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(data, sum); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(data[1:], sum); err == nil {
+		t.Error("Validation should fail for broken content")
+	}
+	if err := v.Validate(data, []byte("abcdef")); err == nil {
+		t.Error("Validation should fail for invalid hash")
+	}
+}
+
+func TestHashSumValidator(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha256sum := sha256.Sum256(data)
+	sha384sum := sha512.Sum384(data)
+	sha512sum := sha512.Sum512(data)
+	b2sum := blake2b.Sum512(data)
+
+	for _, tc := range []struct {
+		algo HashAlgorithm
+		sum  []byte
+	}{
+		{HashSHA256, sha256sum[:]},
+		{HashSHA384, sha384sum[:]},
+		{HashSHA512, sha512sum[:]},
+		{HashBLAKE2b512, b2sum[:]},
+	} {
+		sum := hex.EncodeToString(tc.sum)
+		for _, format := range []struct {
+			what string
+			data string
+		}{
+			{"bare hex", sum},
+			{"bare hex with newline", sum + "\n"},
+			{"upper case", strings.ToUpper(sum) + "\n"},
+			{"with file name", sum + "  foo.zip\n"},
+			{"binary mode", sum + " *foo.zip\n"},
+			{"tab separated", sum + "\tfoo.zip\r\n"},
+			{"BSD format", tc.algo.String() + " (foo.zip) = " + sum + "\n"},
+			{"with comment", "# comment\n\n" + sum + "  foo.zip\n"},
+		} {
+			v := &HashSumValidator{Algorithm: tc.algo}
+			if err := v.Validate(data, []byte(format.data)); err != nil {
+				t.Errorf("Validation of %s with %s failed: %s", tc.algo, format.what, err)
+			}
+			if err := v.Validate(data[1:], []byte(format.data)); err == nil {
+				t.Errorf("Validation of %s with %s should fail for broken content", tc.algo, format.what)
+			}
+		}
+	}
+}
+
+func TestHashSumValidatorFail(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha512sum := sha512.Sum512(data)
+	sum := hex.EncodeToString(sha512sum[:])
+
+	for _, tc := range []struct {
+		what string
+		data string
+		want string
+	}{
+		{"empty", "", "hash was not found"},
+		{"only comment", "# foo\n", "hash was not found"},
+		{"SHA256 hash", sum[:64], "too short"},
+		{"too long hash", sum + "00", "too long"},
+		{"not hex", strings.Repeat("z", 128), "invalid hash"},
+		{"other algorithm", "SHA256 (foo.zip) = " + sum, "hash file is for SHA256"},
+		{"multiple assets", strings.Repeat("0", 128) + "  bar.zip\n" + sum + "  foo.zip\n", "use ChecksumsValidator"},
+	} {
+		err := (&HashSumValidator{Algorithm: HashSHA512}).Validate(data, []byte(tc.data))
+		if err == nil {
+			t.Errorf("Error should occur for %s", tc.what)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Error for %s should contain %q but got %q", tc.what, tc.want, err.Error())
+		}
+	}
+}
+
+func TestHashSumValidatorCustomHash(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2sum := blake2b.Sum256(data)
+	v := &HashSumValidator{
+		Hash: func() hash.Hash {
+			h, _ := blake2b.New256(nil)
+			return h
+		},
+		ValidationSuffix: ".b2-256",
+	}
+	if v.Suffix() != ".b2-256" {
+		t.Error("Unexpected suffix:", v.Suffix())
+	}
+	if err := v.Validate(data, []byte(hex.EncodeToString(b2sum[:])+"  foo.zip\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChecksumsValidatorSHA512(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/foo.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha512sum := sha512.Sum512(data)
+	sum := hex.EncodeToString(sha512sum[:])
+	v := &ChecksumsValidator{Pattern: "SHA512SUMS", Algorithm: HashSHA512}
+
+	selected, err := v.SelectValidationData("foo.zip", []byte("SHA512 (foo.zip) = "+sum+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(data, selected); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.SelectValidationData("foo.zip", []byte("SHA256 (foo.zip) = "+sum[:64]+"\n")); err == nil {
+		t.Error("Checksum for other algorithm should not be selected")
+	}
+}
+
+func TestHashAlgorithmUnknown(t *testing.T) {
+	if _, err := HashAlgorithm(9).New(); err == nil {
+		t.Error("Error should occur for unknown hash algorithm")
+	}
+	if err := (&HashSumValidator{Algorithm: HashAlgorithm(9)}).Validate([]byte("foo"), []byte("00")); err == nil {
+		t.Error("Validation should fail with unknown hash algorithm")
+	}
+	if err := (&ChecksumsValidator{Algorithm: HashAlgorithm(9)}).Validate([]byte("foo"), []byte("00")); err == nil {
+		t.Error("Validation should fail with unknown hash algorithm")
+	}
+}
@@ -0,0 +1,230 @@
+package selfupdate
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// WatchAction represents what Watcher does when an update is available.
+type WatchAction int
+
+const (
+	// WatchNotify only notifies that an update is available.
+	WatchNotify WatchAction = iota
+	// WatchStage stages the update with StageCommand so that it is applied by ApplyStaged on the next start.
+	WatchStage
+	// WatchApply applies the update to the command immediately.
+	WatchApply
+)
+
+const (
+	// defaultWatchInterval is the interval of checks used when WatcherConfig.Interval is zero.
+	defaultWatchInterval = time.Hour
+)
+
+// WatcherConfig represents the configuration of Watcher.
+type WatcherConfig struct {
+	// Slug is 'owner/name' formatted string of the repository to watch.
+	Slug string
+	// Current is the current version of the command.
+	Current semver.Version
+	// CmdPath is a file path to the command executable to stage or apply updates. Empty means the running
+	// executable.
+	CmdPath string
+	// Action is what to do when an update allowed by the update policy is available.
+	Action WatchAction
+	// Interval is the interval of checks. Zero means one hour.
+	Interval time.Duration
+	// Jitter is the maximum random duration added to each interval so that many processes do not check at
+	// the same time. The first check is also delayed by it. Zero means 10% of Interval and a negative value
+	// means no jitter.
+	Jitter time.Duration
+	// MaxBackoff is the maximum interval while checks keep failing. The interval is doubled on each failure
+	// up to it. Zero means 8 times Interval.
+	MaxBackoff time.Duration
+	// OnEvent is called with each event. When it is nil, events are delivered on the channel returned from
+	// Watcher.Events.
+	OnEvent func(WatchEvent)
+}
+
+// WatchEvent is an event delivered by Watcher.
+type WatchEvent struct {
+	// Result is the result of the check. It is nil when the check failed.
+	Result *CheckResult
+	// Staged is true when the update was staged with WatchStage.
+	Staged bool
+	// Applied is true when the update was applied with WatchApply.
+	Applied bool
+	// Err is an error which occurred while checking, staging or applying the update.
+	Err error
+}
+
+// Watcher checks updates of a command periodically in background. It is useful for long-running processes
+// such as daemons. Events are delivered when an update is available (only once per version) or when an
+// error occurred.
+type Watcher struct {
+	up       *Updater
+	config   WatcherConfig
+	events   chan WatchEvent
+	current  semver.Version
+	notified *semver.Version
+	failures uint
+	rand     *rand.Rand
+}
+
+// NewWatcher creates a new Watcher. Please call its Run method to start watching.
+func (up *Updater) NewWatcher(config WatcherConfig) *Watcher {
+	if config.Interval <= 0 {
+		config.Interval = defaultWatchInterval
+	}
+	if config.Jitter == 0 {
+		config.Jitter = config.Interval / 10
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = 8 * config.Interval
+	} else if config.MaxBackoff < config.Interval {
+		config.MaxBackoff = config.Interval
+	}
+	return &Watcher{
+		up:      up,
+		config:  config,
+		events:  make(chan WatchEvent, 1),
+		current: config.Current,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Events returns the channel to receive events. The events must be received unless WatcherConfig.OnEvent is
+// set. The channel is closed when Run returns.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Run checks updates periodically until the context is canceled. It blocks until then and returns the error
+// of the context. A check, staging or applying in progress is also canceled with the context. It must not be called more than once.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	timer := time.NewTimer(w.jitter())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		ev, ok := w.check(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if ok {
+			if !w.deliver(ctx, ev) {
+				return ctx.Err()
+			}
+		}
+		timer.Reset(w.nextInterval())
+	}
+}
+
+func (w *Watcher) jitter() time.Duration {
+	if w.config.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(w.rand.Int63n(int64(w.config.Jitter)))
+}
+
+// nextInterval returns the duration until the next check. It is backed off exponentially while checks fail.
+func (w *Watcher) nextInterval() time.Duration {
+	d := w.config.Interval
+	for i := uint(0); i < w.failures && d < w.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.config.MaxBackoff {
+		d = w.config.MaxBackoff
+	}
+	return d + w.jitter()
+}
+
+func (w *Watcher) deliver(ctx context.Context, ev WatchEvent) bool {
+	if w.config.OnEvent != nil {
+		w.config.OnEvent(ev)
+		return true
+	}
+	select {
+	case w.events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// check checks an update once and does the configured action. Requests and downloads are canceled with the
+// context. It returns false when no event should be delivered.
+func (w *Watcher) check(ctx context.Context) (WatchEvent, bool) {
+	up := w.up.withContext(ctx)
+	res, err := up.Check(w.current, w.config.Slug)
+	if err != nil {
+		if ctx.Err() != nil {
+			return WatchEvent{}, false
+		}
+		w.failures++
+		log.Println("Could not check update of", w.config.Slug, ":", err)
+		return WatchEvent{Err: err}, true
+	}
+	w.failures = 0
+
+	if res.Status != UpdateAvailable {
+		return WatchEvent{}, false
+	}
+	v := res.Release.Version
+	if w.notified != nil && w.notified.Equals(v) {
+		return WatchEvent{}, false
+	}
+
+	ev := WatchEvent{Result: res}
+	if w.config.Action == WatchNotify {
+		w.notified = &v
+		return ev, true
+	}
+
+	cmdPath := w.config.CmdPath
+	if cmdPath == "" {
+		if cmdPath, err = os.Executable(); err != nil {
+			ev.Err = err
+			return ev, true
+		}
+	}
+
+	switch w.config.Action {
+	case WatchStage:
+		if err := up.StageCommand(res.Release, cmdPath); err != nil {
+			ev.Err = err
+			return ev, true
+		}
+		ev.Staged = true
+	case WatchApply:
+		p, err := resolveCommandPath(cmdPath)
+		if err == nil {
+			err = up.UpdateTo(res.Release, p)
+		}
+		if err != nil {
+			ev.Err = err
+			return ev, true
+		}
+		ev.Applied = true
+		w.current = v
+	}
+	w.notified = &v
+	return ev, true
+}
+
+// NewWatcher creates a new Watcher to check updates periodically in background.
+// This function is a shortcut version of updater.NewWatcher.
+func NewWatcher(config WatcherConfig) *Watcher {
+	return DefaultUpdater().NewWatcher(config)
+}
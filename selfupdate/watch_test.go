@@ -0,0 +1,214 @@
+package selfupdate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func TestWatcherNextInterval(t *testing.T) {
+	w := NewWatcher(WatcherConfig{Interval: time.Second, Jitter: -1, MaxBackoff: 4 * time.Second})
+	for _, tc := range []struct {
+		failures uint
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 4 * time.Second},
+		{100, 4 * time.Second},
+	} {
+		w.failures = tc.failures
+		if got := w.nextInterval(); got != tc.want {
+			t.Errorf("Interval after %d failures should be %s but got %s", tc.failures, tc.want, got)
+		}
+	}
+
+	w = NewWatcher(WatcherConfig{Interval: time.Second, Jitter: 100 * time.Millisecond})
+	if w.config.MaxBackoff != 8*time.Second {
+		t.Error("Default max backoff should be 8 times interval:", w.config.MaxBackoff)
+	}
+	for i := 0; i < 100; i++ {
+		if d := w.nextInterval(); d < time.Second || d >= time.Second+100*time.Millisecond {
+			t.Fatal("Interval with jitter is out of range:", d)
+		}
+	}
+}
+
+func TestWatcherNotify(t *testing.T) {
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), binaryRelease("v1.1.0")})
+	defer srv.Close()
+
+	up := newTestUpdater(t, srv, Config{})
+	w := up.NewWatcher(WatcherConfig{
+		Slug:     "owner/repo",
+		Current:  semver.MustParse("1.0.0"),
+		Interval: 5 * time.Millisecond,
+		Jitter:   -1,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case ev := <-w.Events():
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		if ev.Result.Status != UpdateAvailable || !ev.Result.Release.Version.Equals(semver.MustParse("1.1.0")) {
+			t.Errorf("Unexpected result: %+v", ev.Result)
+		}
+		if ev.Staged || ev.Applied {
+			t.Error("Update should only be notified:", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event was not delivered")
+	}
+
+	select {
+	case ev := <-w.Events():
+		t.Error("The same version should be notified only once:", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("Run should return the error of context:", err)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Error("Events channel should be closed")
+	}
+}
+
+func TestWatcherApply(t *testing.T) {
+	rel := zipRelease(t, "v1.1.0", "")
+	srv, _ := newTestAPIServer(t, []testRelease{binaryRelease("v1.0.0"), rel})
+	defer srv.Close()
+
+	exe, cleanup := newTestExecutable(t, "v1.0.0")
+	defer cleanup()
+
+	up := newTestUpdater(t, srv, Config{Validator: &SHA2Validator{}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan WatchEvent, 10)
+	w := up.NewWatcher(WatcherConfig{
+		Slug:     "owner/repo",
+		Current:  semver.MustParse("1.0.0"),
+		CmdPath:  exe,
+		Action:   WatchApply,
+		Interval: 5 * time.Millisecond,
+		Jitter:   -1,
+		OnEvent:  func(ev WatchEvent) { events <- ev },
+	})
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case ev := <-events:
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		if !ev.Applied {
+			t.Error("Update should be applied:", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event was not delivered")
+	}
+	if got := readTestExecutable(t, exe); got != "v1.1.0" {
+		t.Errorf("Executable was not updated: %q", got)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	if len(events) != 0 {
+		t.Error("No more event should be delivered after the update was applied:", <-events)
+	}
+	if !w.current.Equals(semver.MustParse("1.1.0")) {
+		t.Error("Current version should be updated:", w.current)
+	}
+}
+
+func TestWatcherError(t *testing.T) {
+	srv, mux := newTestAPIServer(t, nil)
+	defer srv.Close()
+	mux.HandleFunc("/api/v3/repos/owner/broken/releases", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	})
+
+	up := newTestUpdater(t, srv, Config{})
+	w := up.NewWatcher(WatcherConfig{
+		Slug:     "owner/broken",
+		Current:  semver.MustParse("1.0.0"),
+		Interval: time.Hour,
+		Jitter:   -1,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case ev := <-w.Events():
+		if ev.Err == nil {
+			t.Error("Error should be delivered:", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event was not delivered")
+	}
+	cancel()
+	<-done
+	if w.failures != 1 {
+		t.Error("Failure should be counted for backoff:", w.failures)
+	}
+}
+
+func TestWatcherCancelWhileChecking(t *testing.T) {
+	srv, mux := newTestAPIServer(t, nil)
+	defer srv.Close()
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	mux.HandleFunc("/api/v3/repos/owner/hang/releases", func(w http.ResponseWriter, r *http.Request) {
+		requested <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+
+	up := newTestUpdater(t, srv, Config{})
+	w := up.NewWatcher(WatcherConfig{
+		Slug:     "owner/hang",
+		Current:  semver.MustParse("1.0.0"),
+		Interval: time.Hour,
+		Jitter:   -1,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Releases were not requested")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Error("Run should return the error of context:", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return while the check was hanging")
+	}
+	if w.failures != 0 {
+		t.Error("Canceled check should not be counted as failure:", w.failures)
+	}
+}
@@ -0,0 +1,64 @@
+package selfupdate
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/v30/github"
+)
+
+// isYanked returns true when the release is withdrawn. A release is yanked when its version is listed in
+// Config.YankedVersions, when 'yanked: true' is written in the metadata of its release notes, or when it
+// has a label asset named 'YANKED'.
+func (up *Updater) isYanked(rel *github.RepositoryRelease, ver semver.Version) bool {
+	for _, v := range up.yanked {
+		if v.Equals(ver) {
+			return true
+		}
+	}
+	if parseReleaseMetadata(rel.GetBody()).bool("yanked") {
+		return true
+	}
+	for _, a := range rel.Assets {
+		if strings.EqualFold(a.GetName(), "yanked") {
+			return true
+		}
+	}
+	return false
+}
+
+// versionYanked returns true when the release of the version is yanked in the releases.
+func (up *Updater) versionYanked(rels []*github.RepositoryRelease, ver semver.Version) bool {
+	for _, v := range up.yanked {
+		if v.Equals(ver) {
+			return true
+		}
+	}
+	for _, rel := range rels {
+		if v, ok := parseTagVersion(rel.GetTagName()); ok && v.Equals(ver) && up.isYanked(rel, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsYanked returns true when the given version of the repository was yanked (withdrawn).
+// 'slug' means 'owner/name' formatted string. CLIs can use this to warn users running a yanked version.
+// See Config.YankedVersions for how to mark a release as yanked.
+func (up *Updater) IsYanked(slug string, version semver.Version) (bool, error) {
+	owner, repo, err := parseSlug(slug)
+	if err != nil {
+		return false, err
+	}
+	rels, err := up.fetchReleases(owner, repo)
+	if err != nil {
+		return false, err
+	}
+	return up.versionYanked(rels, version), nil
+}
+
+// IsYanked returns true when the given version of the slug (owner/repo) was yanked (withdrawn).
+// This function is a shortcut version of updater.IsYanked() method.
+func IsYanked(slug string, version semver.Version) (bool, error) {
+	return DefaultUpdater().IsYanked(slug, version)
+}
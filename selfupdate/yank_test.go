@@ -0,0 +1,110 @@
+package selfupdate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func yankedTestReleases() []testRelease {
+	return []testRelease{
+		{tag: "v1.0.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.1.0", assets: []string{platformAsset("foo")}},
+		{tag: "v1.2.0", body: "Broken\n\n<!-- selfupdate\nyanked: true\n-->", assets: []string{platformAsset("foo")}},
+		{tag: "v1.3.0", assets: []string{platformAsset("foo"), "YANKED"}},
+		{tag: "v1.4.0", assets: []string{platformAsset("foo")}},
+	}
+}
+
+func TestDetectLatestSkipsYankedReleases(t *testing.T) {
+	srv, _ := newTestAPIServer(t, yankedTestReleases())
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		what   string
+		config Config
+		want   string
+	}{
+		{"no deny-list", Config{}, "1.4.0"},
+		{"deny-list", Config{YankedVersions: []string{"v1.4.0"}}, "1.1.0"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			up := newTestUpdater(t, srv, tc.config)
+			rel, ok, err := up.DetectLatest("owner/repo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("Release was not found")
+			}
+			if rel.Version.String() != tc.want {
+				t.Errorf("Wanted %s but got %s", tc.want, rel.Version)
+			}
+		})
+	}
+}
+
+func TestListReleasesSkipsYankedReleases(t *testing.T) {
+	srv, _ := newTestAPIServer(t, yankedTestReleases())
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{YankedVersions: []string{"1.0.0"}})
+
+	rels, err := up.ListReleases("owner/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 2 || rels[0].Version.String() != "1.4.0" || rels[1].Version.String() != "1.1.0" {
+		t.Fatalf("Yanked releases should not be listed: %v", rels)
+	}
+}
+
+func TestDetectVersionOfYankedRelease(t *testing.T) {
+	srv, _ := newTestAPIServer(t, yankedTestReleases())
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{})
+
+	rel, ok, err := up.DetectVersion("owner/repo", "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || rel.Version.String() != "1.2.0" {
+		t.Fatal("Explicitly specified yanked version should be detected:", rel)
+	}
+}
+
+func TestIsYanked(t *testing.T) {
+	srv, _ := newTestAPIServer(t, yankedTestReleases())
+	defer srv.Close()
+	up := newTestUpdater(t, srv, Config{YankedVersions: []string{"0.9.0"}})
+
+	for _, tc := range []struct {
+		version string
+		want    bool
+	}{
+		{"1.0.0", false},
+		{"1.2.0", true},
+		{"1.3.0", true},
+		{"1.4.0", false},
+		{"0.9.0", true},
+		{"2.0.0", false},
+	} {
+		yanked, err := up.IsYanked("owner/repo", semver.MustParse(tc.version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if yanked != tc.want {
+			t.Errorf("Wanted %v for version %s but got %v", tc.want, tc.version, yanked)
+		}
+	}
+}
+
+func TestInvalidYankedVersion(t *testing.T) {
+	_, err := NewUpdater(Config{YankedVersions: []string{"foo"}})
+	if err == nil {
+		t.Fatal("Error unexpectedly did not occur")
+	}
+	if !strings.Contains(err.Error(), "Could not parse yanked version \"foo\"") {
+		t.Fatalf("Error message is unexpected: %q", err)
+	}
+}